@@ -1,25 +1,242 @@
 package api
 
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/events"
+	"github.com/AlexAkulov/hungryfox/helpers"
+	"github.com/AlexAkulov/hungryfox/searcher"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+	"gopkg.in/tomb.v2"
+)
+
+type IScanManager interface {
+	Status() *hungryfox.Repo
+	Repos() []hungryfox.Repo
+	Queue() []string
+	ScanRepoURL(url string) error
+	ScanInspect(index int) (int, error)
+}
+
+type ILeakSearcher interface {
+	Status(repoURL string) searcher.RepoStats
+	Config() *config.Config
+	Reload(*config.Config) error
+	Patterns() []config.Pattern
+	Filters() []config.Pattern
+}
+
+// Server - HTTP API
 type Server struct {
+	Listen      string
+	ScanManager IScanManager
+	Searcher    ILeakSearcher
+	LeakStore   hungryfox.ILeakStore
+	Events      *events.Broker
+	Tokens      []config.APIToken
+	AuditLog    string
+	// InsecureNoAuth - everything is allowed without tokens, API must listen loopback address
+	InsecureNoAuth bool
+	// Redaction - redaction of leaks in responses, masked if empty
+	Redaction hungryfox.Redaction
+	Log       zerolog.Logger
+
+	server     *http.Server
+	tomb       tomb.Tomb
+	rulesMutex sync.Mutex
+	auditMutex sync.Mutex
+}
+
+type scanJSON struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Success   bool      `json:"success"`
+}
+
+type repoJSON struct {
+	URL          string   `json:"url"`
+	CloneURL     string   `json:"clone_url,omitempty"`
+	RepoPath     string   `json:"repo_path"`
+	DataPath     string   `json:"data_path"`
+	Scan         scanJSON `json:"scan"`
+	LeaksFound   int      `json:"leaks_found"`
+	LeaksFiltred int      `json:"leaks_filtred"`
+}
+
+type currentScanJSON struct {
+	Repo     repoJSON `json:"repo"`
+	Progress int      `json:"progress"`
+	Duration string   `json:"duration"`
+}
 
+type statusJSON struct {
+	TotalRepos  int              `json:"total_repos"`
+	CurrentScan *currentScanJSON `json:"current_scan"`
+	Queue       []string         `json:"queue"`
 }
 
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+// Start - start listen HTTP API
 func (s *Server) Start() error {
+	if err := ValidateTokens(s.Tokens); err != nil {
+		return err
+	}
+	if s.Redaction != "" && !s.Redaction.IsValid() {
+		return fmt.Errorf("unknown redaction '%s'", s.Redaction)
+	}
+	if len(s.Tokens) == 0 {
+		if !s.InsecureNoAuth {
+			return fmt.Errorf("no api tokens configured, set insecure_no_auth to run API without authentication on loopback address")
+		}
+		if !isLoopback(s.Listen) {
+			return fmt.Errorf("insecure_no_auth is allowed only for loopback address, not for '%s'", s.Listen)
+		}
+		s.Log.Warn().Str("service", "api").Msg("no tokens configured, authentication is disabled")
+	}
+	listener, err := net.Listen("tcp", s.Listen)
+	if err != nil {
+		return err
+	}
+	s.server = &http.Server{Handler: s.handler()}
+	s.tomb.Go(func() error {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
 	return nil
 }
 
-func AddFilter() error {
-	return nil
+// isLoopback - listen address is available only from this host
+func isLoopback(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
-func AddPattern() error {
-	return nil
+// Stop - stop HTTP API
+func (s *Server) Stop() error {
+	s.tomb.Kill(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	return s.tomb.Wait()
 }
 
-func MarkLeakAsResolved() error {
-	return nil
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/status", s.authorize(scope(ScopeReadStatus), s.onlyGet(s.Status)))
+	mux.HandleFunc("/api/v1/repos", s.authorize(scope(ScopeReadStatus), s.onlyGet(s.Repos)))
+	mux.HandleFunc("/api/v1/scan", s.authorize(byMethod(ScopeReadStatus, ScopeScan), s.Scan))
+	mux.HandleFunc("/api/v1/patterns", s.authorize(byMethod(ScopeReadStatus, ScopeManagePatterns), s.Patterns))
+	mux.HandleFunc("/api/v1/filters", s.authorize(byMethod(ScopeReadStatus, ScopeManagePatterns), s.Filters))
+	mux.HandleFunc("/api/v1/leaks", s.authorize(scope(ScopeReadLeaks), s.onlyGet(s.Leaks)))
+	mux.HandleFunc("/api/v1/leaks/", s.authorize(byMethod(ScopeReadLeaks, ScopeTriage), s.Leak))
+	mux.HandleFunc("/api/v1/events", s.authorize(scope(ScopeReadLeaks), s.onlyGet(s.EventStream)))
+	mux.Handle("/metrics", s.authorize(scope(ScopeReadStatus), promhttp.Handler().ServeHTTP))
+	mux.HandleFunc("/", s.onlyGet(s.Index))
+	return mux
+}
+
+// Status - total repos and current scan
+func (s *Server) Status(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, statusJSON{
+		TotalRepos:  len(s.ScanManager.Repos()),
+		CurrentScan: s.currentScan(),
+		Queue:       s.ScanManager.Queue(),
+	})
+}
+
+// Repos - list of all repos with last scan status and leaks counters
+func (s *Server) Repos(w http.ResponseWriter, r *http.Request) {
+	repos := s.ScanManager.Repos()
+	result := make([]repoJSON, 0, len(repos))
+	for _, repo := range repos {
+		result = append(result, s.convertRepo(repo))
+	}
+	s.writeJSON(w, http.StatusOK, result)
 }
 
-func Status() {
+// Scan - get repo which is scanning now or add repos to scan queue
+func (s *Server) Scan(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.writeJSON(w, http.StatusOK, s.currentScan())
+	case http.MethodPost:
+		s.EnqueueScan(w, r)
+	default:
+		s.writeJSON(w, http.StatusMethodNotAllowed, errorJSON{Error: "method not allowed"})
+	}
+}
+
+func (s *Server) currentScan() *currentScanJSON {
+	repo := s.ScanManager.Status()
+	if repo == nil {
+		return nil
+	}
+	progress := -1
+	if repo.Repo != nil {
+		progress = repo.Repo.GetProgress()
+	}
+	return &currentScanJSON{
+		Repo:     s.convertRepo(*repo),
+		Progress: progress,
+		Duration: helpers.PrettyDuration(time.Since(repo.Scan.StartTime)),
+	}
+}
+
+func (s *Server) convertRepo(r hungryfox.Repo) repoJSON {
+	stats := s.Searcher.Status(r.Location.URL)
+	return repoJSON{
+		URL:      r.Location.URL,
+		CloneURL: r.Location.CloneURL,
+		RepoPath: r.Location.RepoPath,
+		DataPath: r.Location.DataPath,
+		Scan: scanJSON{
+			StartTime: r.Scan.StartTime,
+			EndTime:   r.Scan.EndTime,
+			Success:   r.Scan.Success,
+		},
+		LeaksFound:   stats.LeaksFound,
+		LeaksFiltred: stats.LeaksFiltred,
+	}
+}
+
+func (s *Server) onlyGet(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			s.writeJSON(w, http.StatusMethodNotAllowed, errorJSON{Error: "method not allowed"})
+			return
+		}
+		handler(w, r)
+	}
+}
 
+func (s *Server) writeJSON(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		s.Log.Error().Str("error", err.Error()).Msg("can't write response")
+	}
 }
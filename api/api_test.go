@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/searcher"
+
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeScanManager struct {
+	current *hungryfox.Repo
+	repos   []hungryfox.Repo
+	queue   []string
+}
+
+func (f *fakeScanManager) Status() *hungryfox.Repo { return f.current }
+func (f *fakeScanManager) Repos() []hungryfox.Repo { return f.repos }
+func (f *fakeScanManager) Queue() []string         { return f.queue }
+
+func (f *fakeScanManager) ScanRepoURL(url string) error {
+	for _, r := range f.repos {
+		if r.Location.URL == url {
+			f.queue = append(f.queue, url)
+			return nil
+		}
+	}
+	return fmt.Errorf("repo '%s' not found", url)
+}
+
+func (f *fakeScanManager) ScanInspect(index int) (int, error) {
+	count := 0
+	for _, r := range f.repos {
+		if r.Options.Inspect == index {
+			f.queue = append(f.queue, r.Location.URL)
+			count++
+		}
+	}
+	return count, nil
+}
+
+type fakeSearcher struct {
+	stats     map[string]searcher.RepoStats
+	config    *config.Config
+	updated   int
+	reloadErr error
+}
+
+func (f *fakeSearcher) Status(repoURL string) searcher.RepoStats { return f.stats[repoURL] }
+func (f *fakeSearcher) Config() *config.Config                   { return f.config }
+func (f *fakeSearcher) Reload(*config.Config) error              { f.updated++; return f.reloadErr }
+func (f *fakeSearcher) Patterns() []config.Pattern               { return nil }
+func (f *fakeSearcher) Filters() []config.Pattern                { return nil }
+
+func TestServer(t *testing.T) {
+	Convey("Test API", t, func() {
+		repos := []hungryfox.Repo{
+			{Location: hungryfox.RepoLocation{URL: "https://example.com/a", RepoPath: "a"}},
+			{Location: hungryfox.RepoLocation{URL: "https://example.com/b", RepoPath: "b"}, Options: hungryfox.RepoOptions{Inspect: 1}},
+		}
+		sm := &fakeScanManager{repos: repos}
+		s := &Server{
+			ScanManager: sm,
+			Searcher: &fakeSearcher{
+				stats: map[string]searcher.RepoStats{"https://example.com/b": {LeaksFound: 3, LeaksFiltred: 1}},
+			},
+			InsecureNoAuth: true,
+			Log:            zerolog.Nop(),
+		}
+		h := s.handler()
+
+		Convey("repos", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/repos", nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			result := []repoJSON{}
+			So(json.Unmarshal(w.Body.Bytes(), &result), ShouldBeNil)
+			So(len(result), ShouldEqual, 2)
+			So(result[1].URL, ShouldEqual, "https://example.com/b")
+			So(result[1].LeaksFound, ShouldEqual, 3)
+			So(result[1].LeaksFiltred, ShouldEqual, 1)
+		})
+
+		Convey("status without scan", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/status", nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			result := statusJSON{}
+			So(json.Unmarshal(w.Body.Bytes(), &result), ShouldBeNil)
+			So(result.TotalRepos, ShouldEqual, 2)
+			So(result.CurrentScan, ShouldBeNil)
+		})
+
+		Convey("current scan", func() {
+			sm.current = &repos[0]
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/scan", nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			result := currentScanJSON{}
+			So(json.Unmarshal(w.Body.Bytes(), &result), ShouldBeNil)
+			So(result.Repo.URL, ShouldEqual, "https://example.com/a")
+			So(result.Progress, ShouldEqual, -1)
+		})
+
+		Convey("scan repo", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/scan", strings.NewReader(`{"repo_url":"https://example.com/a"}`)))
+			So(w.Code, ShouldEqual, http.StatusAccepted)
+			So(sm.queue, ShouldResemble, []string{"https://example.com/a"})
+
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/scan", strings.NewReader(`{"inspect":1}`)))
+			So(w.Code, ShouldEqual, http.StatusAccepted)
+			result := scanQueueJSON{}
+			So(json.Unmarshal(w.Body.Bytes(), &result), ShouldBeNil)
+			So(result.Added, ShouldEqual, 1)
+			So(result.Queue, ShouldResemble, []string{"https://example.com/a", "https://example.com/b"})
+
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/status", nil))
+			status := statusJSON{}
+			So(json.Unmarshal(w.Body.Bytes(), &status), ShouldBeNil)
+			So(status.Queue, ShouldResemble, []string{"https://example.com/a", "https://example.com/b"})
+		})
+
+		Convey("scan unknown repo", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/scan", strings.NewReader(`{"repo_url":"https://example.com/c"}`)))
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/scan", strings.NewReader(`{}`)))
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("dashboard", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Type"), ShouldStartWith, "text/html")
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/unknown", nil))
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("metrics", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldContainSubstring, "hungryfox_commits_scanned_total")
+		})
+
+		Convey("wrong method", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/repos", nil))
+			So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		})
+	})
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlexAkulov/hungryfox/config"
+)
+
+const (
+	ScopeReadStatus     = "read-status"
+	ScopeReadLeaks      = "read-leaks"
+	ScopeManagePatterns = "manage-patterns"
+	ScopeTriage         = "triage"
+	ScopeScan           = "scan"
+)
+
+var knownScopes = map[string]struct{}{
+	ScopeReadStatus:     {},
+	ScopeReadLeaks:      {},
+	ScopeManagePatterns: {},
+	ScopeTriage:         {},
+	ScopeScan:           {},
+}
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// maxAuditBody - only beginning of request body is read for audit payload
+const maxAuditBody = 64 * 1024
+
+type auditRecord struct {
+	TimeStamp  time.Time     `json:"ts"`
+	User       string        `json:"user"`
+	RemoteAddr string        `json:"remote_addr"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	Status     int           `json:"status"`
+	Payload    *auditPayload `json:"payload,omitempty"`
+}
+
+// auditPayload - what mutating request changes, it is taken from request even if it is rejected
+type auditPayload struct {
+	Rule    string `json:"rule,omitempty"`
+	LeakID  string `json:"leak_id,omitempty"`
+	State   string `json:"state,omitempty"`
+	RepoURL string `json:"repo_url,omitempty"`
+	Inspect *int   `json:"inspect,omitempty"`
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// ValidateTokens - check that tokens have names and known scopes
+func ValidateTokens(tokens []config.APIToken) error {
+	names := map[string]struct{}{}
+	for _, token := range tokens {
+		if token.Name == "" {
+			return fmt.Errorf("token name is required")
+		}
+		if _, ok := names[token.Name]; ok {
+			return fmt.Errorf("duplicate token name '%s'", token.Name)
+		}
+		names[token.Name] = struct{}{}
+		if token.Token == "" {
+			return fmt.Errorf("token '%s' is empty", token.Name)
+		}
+		for _, scope := range token.Scopes {
+			if _, ok := knownScopes[scope]; !ok {
+				return fmt.Errorf("unknown scope '%s' in token '%s'", scope, token.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// byMethod - scope for read requests and scope for mutating requests
+func byMethod(readScope, writeScope string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return readScope
+		}
+		return writeScope
+	}
+}
+
+func scope(name string) func(*http.Request) string {
+	return func(*http.Request) string { return name }
+}
+
+// authorize - check bearer token and its scope, write mutating calls to audit log, rejected ones too.
+// Everything is allowed only in insecure_no_auth mode without tokens
+func (s *Server) authorize(getScope func(*http.Request) string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mutating := r.Method != http.MethodGet && r.Method != http.MethodHead
+		var payload *auditPayload
+		if mutating {
+			payload = readAuditPayload(r)
+		}
+		if len(s.Tokens) > 0 || !s.InsecureNoAuth {
+			token, ok := s.findToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="hungryfox"`)
+				s.writeJSON(w, http.StatusUnauthorized, errorJSON{Error: "unauthorized"})
+				if mutating {
+					s.audit(newAuditRecord(r, "", http.StatusUnauthorized, payload))
+				}
+				return
+			}
+			if !hasScope(token, getScope(r)) {
+				s.writeJSON(w, http.StatusForbidden, errorJSON{Error: fmt.Sprintf("scope '%s' is required", getScope(r))})
+				if mutating {
+					s.audit(newAuditRecord(r, token.Name, http.StatusForbidden, payload))
+				}
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), userContextKey, token.Name))
+		}
+		if !mutating {
+			handler(w, r)
+			return
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+		s.audit(newAuditRecord(r, userFromRequest(r), recorder.status, payload))
+	}
+}
+
+func newAuditRecord(r *http.Request, user string, status int, payload *auditPayload) auditRecord {
+	return auditRecord{
+		TimeStamp:  time.Now().UTC(),
+		User:       user,
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     status,
+		Payload:    payload,
+	}
+}
+
+// readAuditPayload - rule name, leak id and state or repo of request, body stays readable for handler
+func readAuditPayload(r *http.Request) *auditPayload {
+	payload := &auditPayload{}
+	if id := strings.TrimPrefix(r.URL.Path, "/api/v1/leaks/"); id != r.URL.Path {
+		payload.LeakID = strings.Split(strings.Trim(id, "/"), "/")[0]
+	}
+	if r.Body != nil {
+		rawData, _ := ioutil.ReadAll(io.LimitReader(r.Body, maxAuditBody))
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(rawData), r.Body), r.Body}
+		body := struct {
+			Name    string `json:"name"`
+			State   string `json:"state"`
+			RepoURL string `json:"repo_url"`
+			Inspect *int   `json:"inspect"`
+		}{}
+		if json.Unmarshal(rawData, &body) == nil {
+			payload.Rule, payload.State, payload.RepoURL, payload.Inspect = body.Name, body.State, body.RepoURL, body.Inspect
+		}
+	}
+	if *payload == (auditPayload{}) {
+		return nil
+	}
+	return payload
+}
+
+func (s *Server) findToken(r *http.Request) (config.APIToken, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return config.APIToken{}, false
+	}
+	value := []byte(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+	for _, token := range s.Tokens {
+		if subtle.ConstantTimeCompare(value, []byte(token.Token)) == 1 {
+			return token, true
+		}
+	}
+	return config.APIToken{}, false
+}
+
+func hasScope(token config.APIToken, scope string) bool {
+	for _, s := range token.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func userFromRequest(r *http.Request) string {
+	user, _ := r.Context().Value(userContextKey).(string)
+	return user
+}
+
+func (s *Server) audit(record auditRecord) {
+	s.Log.Info().Str("user", record.User).Str("remote_addr", record.RemoteAddr).Str("method", record.Method).Str("path", record.Path).Int("status", record.Status).Interface("payload", record.Payload).Msg("audit")
+	if s.AuditLog == "" {
+		return
+	}
+	line, _ := json.Marshal(record)
+	s.auditMutex.Lock()
+	defer s.auditMutex.Unlock()
+	f, err := os.OpenFile(s.AuditLog, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		s.Log.Error().Str("error", err.Error()).Str("file", s.AuditLog).Msg("can't write audit log")
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		s.Log.Error().Str("error", err.Error()).Str("file", s.AuditLog).Msg("can't write audit log")
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/state/leakstate"
+
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuthorize(t *testing.T) {
+	Convey("Test API authorization", t, func() {
+		dir, err := ioutil.TempDir("", "hungryfox")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		store := &leakstate.Store{}
+		So(store.Start(), ShouldBeNil)
+		leak := hungryfox.Leak{RepoURL: "https://example.com/repo", FilePath: "a.txt", LeakString: "secret"}
+		store.Register(leak)
+
+		s := &Server{
+			ScanManager: &fakeScanManager{},
+			Searcher:    &fakeSearcher{},
+			LeakStore:   store,
+			Tokens: []config.APIToken{
+				{Name: "dashboard", Token: "token1", Scopes: []string{ScopeReadStatus}},
+				{Name: "alice", Token: "token2", Scopes: []string{ScopeReadLeaks, ScopeTriage}},
+			},
+			AuditLog: filepath.Join(dir, "audit.log"),
+			Log:      zerolog.Nop(),
+		}
+		h := s.handler()
+		do := func(method, url, token, body string) int {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(method, url, strings.NewReader(body))
+			if token != "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			h.ServeHTTP(w, r)
+			return w.Code
+		}
+
+		Convey("without token", func() {
+			So(do("GET", "/api/v1/status", "", ""), ShouldEqual, http.StatusUnauthorized)
+			So(do("GET", "/api/v1/status", "wrong", ""), ShouldEqual, http.StatusUnauthorized)
+			So(do("GET", "/", "", ""), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("scopes", func() {
+			So(do("GET", "/api/v1/status", "token1", ""), ShouldEqual, http.StatusOK)
+			So(do("GET", "/api/v1/leaks", "token1", ""), ShouldEqual, http.StatusForbidden)
+			So(do("GET", "/api/v1/leaks", "token2", ""), ShouldEqual, http.StatusOK)
+			So(do("GET", "/api/v1/status", "token2", ""), ShouldEqual, http.StatusForbidden)
+			So(do("POST", "/api/v1/filters", "token2", `{"content":"test"}`), ShouldEqual, http.StatusForbidden)
+		})
+
+		Convey("triage by token name with audit", func() {
+			body := `{"state":"false_positive","user":"mallory"}`
+			So(do("POST", "/api/v1/leaks/"+leak.Fingerprint(false)+"/state", "token2", body), ShouldEqual, http.StatusOK)
+			record, _ := store.Get(leak.Fingerprint(false))
+			So(record.ChangedBy, ShouldEqual, "alice")
+
+			rawData, err := ioutil.ReadFile(s.AuditLog)
+			So(err, ShouldBeNil)
+			audit := auditRecord{}
+			So(json.Unmarshal(rawData, &audit), ShouldBeNil)
+			So(audit.User, ShouldEqual, "alice")
+			So(audit.Method, ShouldEqual, "POST")
+			So(audit.Status, ShouldEqual, http.StatusOK)
+			So(audit.Payload, ShouldResemble, &auditPayload{LeakID: leak.Fingerprint(false), State: "false_positive"})
+		})
+
+		Convey("rejected mutating calls are audited with payload", func() {
+			So(do("POST", "/api/v1/patterns", "token2", `{"name":"my pattern","content":"test"}`), ShouldEqual, http.StatusForbidden)
+			So(do("POST", "/api/v1/leaks/"+leak.Fingerprint(false)+"/state", "", `{"state":"closed"}`), ShouldEqual, http.StatusUnauthorized)
+			So(do("GET", "/api/v1/leaks", "", ""), ShouldEqual, http.StatusUnauthorized)
+
+			rawData, err := ioutil.ReadFile(s.AuditLog)
+			So(err, ShouldBeNil)
+			lines := strings.Split(strings.TrimSpace(string(rawData)), "\n")
+			So(lines, ShouldHaveLength, 2)
+			audit := auditRecord{}
+			So(json.Unmarshal([]byte(lines[0]), &audit), ShouldBeNil)
+			So(audit.User, ShouldEqual, "alice")
+			So(audit.Status, ShouldEqual, http.StatusForbidden)
+			So(audit.Payload, ShouldResemble, &auditPayload{Rule: "my pattern"})
+			audit = auditRecord{}
+			So(json.Unmarshal([]byte(lines[1]), &audit), ShouldBeNil)
+			So(audit.User, ShouldBeEmpty)
+			So(audit.Status, ShouldEqual, http.StatusUnauthorized)
+			So(audit.Payload, ShouldResemble, &auditPayload{LeakID: leak.Fingerprint(false), State: "closed"})
+		})
+	})
+
+	Convey("Test tokens validation", t, func() {
+		So(ValidateTokens([]config.APIToken{{Name: "a", Token: "1", Scopes: []string{ScopeTriage}}}), ShouldBeNil)
+		So(ValidateTokens([]config.APIToken{{Name: "a", Token: "1", Scopes: []string{"admin"}}}), ShouldNotBeNil)
+		So(ValidateTokens([]config.APIToken{{Name: "a", Token: ""}}), ShouldNotBeNil)
+		So(ValidateTokens([]config.APIToken{{Name: "a", Token: "1"}, {Name: "a", Token: "2"}}), ShouldNotBeNil)
+	})
+}
+
+func TestNoAuth(t *testing.T) {
+	Convey("Test API without tokens", t, func() {
+		Convey("doesn't start without insecure_no_auth", func() {
+			s := &Server{Listen: "127.0.0.1:0", Log: zerolog.Nop()}
+			So(s.Start(), ShouldNotBeNil)
+		})
+
+		Convey("insecure_no_auth is rejected for non-loopback address", func() {
+			for _, listen := range []string{":8090", "0.0.0.0:8090", "10.0.0.1:8090"} {
+				s := &Server{Listen: listen, InsecureNoAuth: true, Log: zerolog.Nop()}
+				So(s.Start(), ShouldNotBeNil)
+			}
+		})
+
+		Convey("insecure_no_auth on loopback address", func() {
+			s := &Server{Listen: "127.0.0.1:0", InsecureNoAuth: true, ScanManager: &fakeScanManager{}, Log: zerolog.Nop()}
+			So(s.Start(), ShouldBeNil)
+			So(s.Stop(), ShouldBeNil)
+		})
+
+		Convey("everything is denied without insecure_no_auth", func() {
+			s := &Server{ScanManager: &fakeScanManager{}, Searcher: &fakeSearcher{}, Log: zerolog.Nop()}
+			w := httptest.NewRecorder()
+			s.handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/status", nil))
+			So(w.Code, ShouldEqual, http.StatusUnauthorized)
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const keepAliveInterval = 30 * time.Second
+
+// EventStream - stream of leaks and scan events as Server-Sent Events.
+// Event types can be limited like /api/v1/events?types=leak,scan_finished
+func (s *Server) EventStream(w http.ResponseWriter, r *http.Request) {
+	if s.Events == nil {
+		s.writeJSON(w, http.StatusNotFound, errorJSON{Error: "events are disabled"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeJSON(w, http.StatusInternalServerError, errorJSON{Error: "streaming is not supported"})
+		return
+	}
+	types := map[string]bool{}
+	if query := r.URL.Query().Get("types"); query != "" {
+		for _, t := range strings.Split(query, ",") {
+			types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	ch := s.Events.Subscribe()
+	defer s.Events.Unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.tomb.Dying():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case e := <-ch:
+			if len(types) > 0 && !types[e.Type] {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				s.Log.Error().Str("error", err.Error()).Msg("can't marshal event")
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			flusher.Flush()
+		}
+	}
+}
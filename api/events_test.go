@@ -0,0 +1,41 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AlexAkulov/hungryfox/events"
+
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventStream(t *testing.T) {
+	Convey("Test event stream", t, func() {
+		broker := &events.Broker{}
+		s := &Server{Events: broker, InsecureNoAuth: true, Log: zerolog.Nop()}
+		ts := httptest.NewServer(s.handler())
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/api/v1/events?types=scan_finished")
+		So(err, ShouldBeNil)
+		defer resp.Body.Close()
+		So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		So(resp.Header.Get("Content-Type"), ShouldEqual, "text/event-stream")
+
+		broker.Publish(events.ScanStarted, events.ScanData{RepoURL: "https://example.com/a"})
+		broker.Publish(events.ScanFinished, events.ScanData{RepoURL: "https://example.com/a", Success: true})
+
+		reader := bufio.NewReader(resp.Body)
+		line, err := reader.ReadString('\n')
+		So(err, ShouldBeNil)
+		So(line, ShouldEqual, "event: scan_finished\n")
+		line, err = reader.ReadString('\n')
+		So(err, ShouldBeNil)
+		So(strings.HasPrefix(line, "data: {\"type\":\"scan_finished\""), ShouldBeTrue)
+		So(line, ShouldContainSubstring, `"repo_url":"https://example.com/a"`)
+	})
+}
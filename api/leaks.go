@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexAkulov/hungryfox"
+)
+
+const (
+	defaultLeaksLimit = 100
+	maxLeaksLimit     = 1000
+)
+
+type leaksJSON struct {
+	Total  int                    `json:"total"`
+	Offset int                    `json:"offset"`
+	Limit  int                    `json:"limit"`
+	Items  []hungryfox.LeakRecord `json:"items"`
+}
+
+type leakFilter struct {
+	RepoURL     string
+	PatternName string
+	Email       string
+	FileGlob    string
+	CommitHash  string
+	State       hungryfox.LeakState
+	Severity    hungryfox.Severity
+	Since       time.Time
+	Until       time.Time
+}
+
+func parseLeakFilter(query url.Values) (leakFilter, error) {
+	f := leakFilter{
+		RepoURL:     query.Get("repo_url"),
+		PatternName: query.Get("pattern"),
+		Email:       query.Get("email"),
+		FileGlob:    query.Get("file"),
+		CommitHash:  query.Get("commit"),
+		State:       hungryfox.LeakState(query.Get("state")),
+		Severity:    hungryfox.Severity(query.Get("severity")),
+	}
+	if f.FileGlob != "" {
+		if _, err := path.Match(f.FileGlob, ""); err != nil {
+			return f, fmt.Errorf("bad file glob '%s': %v", f.FileGlob, err)
+		}
+	}
+	if f.State != "" && !f.State.IsValid() {
+		return f, fmt.Errorf("unknown state '%s'", f.State)
+	}
+	if f.Severity != "" && !f.Severity.IsValid() {
+		return f, fmt.Errorf("unknown severity '%s'", f.Severity)
+	}
+	var err error
+	if since := query.Get("since"); since != "" {
+		if f.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return f, fmt.Errorf("bad since: %v", err)
+		}
+	}
+	if until := query.Get("until"); until != "" {
+		if f.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return f, fmt.Errorf("bad until: %v", err)
+		}
+	}
+	return f, nil
+}
+
+func (f leakFilter) match(record hungryfox.LeakRecord) bool {
+	leak := record.Leak
+	if f.RepoURL != "" && leak.RepoURL != f.RepoURL && !foundInRepo(record, f.RepoURL) {
+		return false
+	}
+	if f.PatternName != "" && leak.PatternName != f.PatternName {
+		return false
+	}
+	if f.Email != "" && !strings.EqualFold(leak.CommitEmail, f.Email) {
+		return false
+	}
+	if f.CommitHash != "" && !strings.HasPrefix(leak.CommitHash, f.CommitHash) {
+		return false
+	}
+	if f.State != "" && record.State != f.State {
+		return false
+	}
+	if f.Severity != "" && leak.Severity != f.Severity {
+		return false
+	}
+	if !f.Since.IsZero() && leak.TimeStamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !leak.TimeStamp.Before(f.Until) {
+		return false
+	}
+	if f.FileGlob != "" {
+		if ok, _ := path.Match(f.FileGlob, leak.FilePath); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func foundInRepo(record hungryfox.LeakRecord, repoURL string) bool {
+	for _, location := range record.Locations {
+		if location.RepoURL == repoURL {
+			return true
+		}
+	}
+	return false
+}
+
+func parseInt(query url.Values, key string, defaultValue int) (int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil || result < 0 {
+		return 0, fmt.Errorf("bad %s '%s'", key, value)
+	}
+	return result, nil
+}
+
+// Leaks - search leaks with filters by repo_url (including other locations), pattern, severity, email, file (glob), commit, state,
+// since and until (RFC3339) with pagination by offset and limit
+func (s *Server) Leaks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := parseLeakFilter(query)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+	offset, err := parseInt(query, "offset", 0)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+	limit, err := parseInt(query, "limit", defaultLeaksLimit)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+	if limit > maxLeaksLimit {
+		limit = maxLeaksLimit
+	}
+
+	result := leaksJSON{
+		Offset: offset,
+		Limit:  limit,
+		Items:  []hungryfox.LeakRecord{},
+	}
+	for _, record := range s.LeakStore.List() {
+		if !filter.match(record) {
+			continue
+		}
+		if result.Total >= offset && len(result.Items) < limit {
+			result.Items = append(result.Items, s.redact(record))
+		}
+		result.Total++
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+type leakStateJSON struct {
+	State   hungryfox.LeakState `json:"state"`
+	User    string              `json:"user"`
+	Comment string              `json:"comment"`
+}
+
+// Leak - get leak by GET /api/v1/leaks/<id> or change its triage state by POST /api/v1/leaks/<id>/state
+func (s *Server) Leak(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/leaks/"), "/"), "/")
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.getLeak(w, parts[0])
+	case len(parts) == 2 && parts[1] == "state" && r.Method == http.MethodPost:
+		s.MarkLeak(w, r, parts[0])
+	case len(parts) > 2:
+		s.writeJSON(w, http.StatusNotFound, errorJSON{Error: "not found"})
+	default:
+		s.writeJSON(w, http.StatusMethodNotAllowed, errorJSON{Error: "method not allowed"})
+	}
+}
+
+func (s *Server) getLeak(w http.ResponseWriter, id string) {
+	record, ok := s.LeakStore.Get(id)
+	if !ok {
+		s.writeJSON(w, http.StatusNotFound, errorJSON{Error: fmt.Sprintf("leak '%s' not found", id)})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.redact(record))
+}
+
+// redact - record with redacted leak, secrets are masked by default
+func (s *Server) redact(record hungryfox.LeakRecord) hungryfox.LeakRecord {
+	redaction := s.Redaction
+	if redaction == "" {
+		redaction = hungryfox.RedactionMasked
+	}
+	record.Leak = record.Leak.Redact(redaction)
+	return record
+}
+
+// MarkLeak - change triage state of leak, e.g. mark it as resolved or false positive
+func (s *Server) MarkLeak(w http.ResponseWriter, r *http.Request, id string) {
+	req := leakStateJSON{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: fmt.Sprintf("can't parse request with: %v", err)})
+		return
+	}
+	if !req.State.IsValid() {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: fmt.Sprintf("unknown state '%s'", req.State)})
+		return
+	}
+	if _, ok := s.LeakStore.Get(id); !ok {
+		s.writeJSON(w, http.StatusNotFound, errorJSON{Error: fmt.Sprintf("leak '%s' not found", id)})
+		return
+	}
+	user := userFromRequest(r)
+	if user == "" {
+		user = req.User
+	}
+	record, err := s.LeakStore.SetState(id, req.State, user, req.Comment)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+	s.Log.Info().Str("id", id).Str("state", string(req.State)).Str("user", user).Msg("leak state changed")
+	s.writeJSON(w, http.StatusOK, s.redact(record))
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/state/leakstate"
+
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMarkLeak(t *testing.T) {
+	Convey("Test leak triage", t, func() {
+		store := &leakstate.Store{}
+		So(store.Start(), ShouldBeNil)
+		leak := hungryfox.Leak{RepoURL: "https://example.com/repo", FilePath: "a.txt", LeakString: "secret"}
+		store.Register(leak)
+		id := leak.Fingerprint(false)
+
+		s := &Server{LeakStore: store, InsecureNoAuth: true, Log: zerolog.Nop()}
+		h := s.handler()
+
+		Convey("get leak", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/leaks/"+id, nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			record := hungryfox.LeakRecord{}
+			So(json.Unmarshal(w.Body.Bytes(), &record), ShouldBeNil)
+			So(record.Leak.ID, ShouldEqual, id)
+			So(record.State, ShouldEqual, hungryfox.LeakOpen)
+			So(record.Leak.LeakString, ShouldEqual, "******")
+		})
+
+		Convey("mark as resolved", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/leaks/"+id+"/state", strings.NewReader(`{"state":"resolved","user":"alice"}`)))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			record, _ := store.Get(id)
+			So(record.State, ShouldEqual, hungryfox.LeakResolved)
+			So(record.ChangedBy, ShouldEqual, "alice")
+		})
+
+		Convey("unknown state", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/leaks/"+id+"/state", strings.NewReader(`{"state":"fixed"}`)))
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("unknown leak", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/leaks/unknown", nil))
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
+
+func TestLeaks(t *testing.T) {
+	Convey("Test leaks search", t, func() {
+		store := &leakstate.Store{}
+		So(store.Start(), ShouldBeNil)
+		ts := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+		leaks := []hungryfox.Leak{
+			{RepoURL: "https://example.com/a", FilePath: "config/app.yml", PatternName: "password", CommitHash: "aaa111", CommitEmail: "alice@example.com", LeakString: "1", TimeStamp: ts, Severity: hungryfox.SeverityHigh},
+			{RepoURL: "https://example.com/a", FilePath: "main.go", PatternName: "token", CommitHash: "bbb222", CommitEmail: "bob@example.com", LeakString: "2", TimeStamp: ts.Add(24 * time.Hour)},
+			{RepoURL: "https://example.com/b", FilePath: "config/db.yml", PatternName: "password", CommitHash: "ccc333", CommitEmail: "Alice@example.com", LeakString: "3", TimeStamp: ts.Add(48 * time.Hour)},
+		}
+		for _, leak := range leaks {
+			store.Register(leak)
+		}
+		store.SetState(leaks[2].Fingerprint(false), hungryfox.LeakFalsePositive, "", "")
+		fork := leaks[0]
+		fork.RepoURL = "https://example.com/fork"
+		store.Register(fork)
+
+		s := &Server{LeakStore: store, InsecureNoAuth: true, Redaction: hungryfox.RedactionFull, Log: zerolog.Nop()}
+		h := s.handler()
+		search := func(query string) leaksJSON {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/leaks?"+query, nil))
+			So(w.Code, ShouldEqual, http.StatusOK)
+			result := leaksJSON{}
+			So(json.Unmarshal(w.Body.Bytes(), &result), ShouldBeNil)
+			return result
+		}
+		found := func(result leaksJSON) []string {
+			items := []string{}
+			for _, item := range result.Items {
+				items = append(items, item.Leak.LeakString)
+			}
+			sort.Strings(items)
+			return items
+		}
+
+		Convey("without filters", func() {
+			result := search("")
+			So(result.Total, ShouldEqual, 3)
+			So(result.Limit, ShouldEqual, defaultLeaksLimit)
+		})
+		Convey("by fields", func() {
+			So(found(search("repo_url=https://example.com/a")), ShouldResemble, []string{"1", "2"})
+			So(found(search("repo_url=https://example.com/fork")), ShouldResemble, []string{"1"})
+			So(found(search("pattern=password")), ShouldResemble, []string{"1", "3"})
+			So(found(search("email=alice@example.com")), ShouldResemble, []string{"1", "3"})
+			So(found(search("file=config/*.yml")), ShouldResemble, []string{"1", "3"})
+			So(found(search("commit=bbb")), ShouldResemble, []string{"2"})
+			So(found(search("severity=high")), ShouldResemble, []string{"1"})
+			So(found(search("state=open")), ShouldResemble, []string{"1", "2"})
+			So(found(search("since=2019-05-02T00:00:00Z&until=2019-05-03T00:00:00Z")), ShouldResemble, []string{"2"})
+		})
+		Convey("pagination", func() {
+			result := search("offset=1&limit=1")
+			So(result.Total, ShouldEqual, 3)
+			So(len(result.Items), ShouldEqual, 1)
+			So(result.Items[0].Leak.ID, ShouldEqual, store.List()[1].Leak.ID)
+		})
+		Convey("bad query", func() {
+			for _, query := range []string{"limit=-1", "state=fixed", "severity=urgent", "since=yesterday", "file=["} {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/leaks?"+query, nil))
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+			}
+		})
+	})
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/helpers"
+	"github.com/AlexAkulov/hungryfox/searcher"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// rules added through API are saved to this file near patterns_path or filters_path
+const runtimeRulesFile = "api.yml"
+
+// Patterns - list loaded patterns or add new one
+func (s *Server) Patterns(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.writeJSON(w, http.StatusOK, s.Searcher.Patterns())
+	case http.MethodPost:
+		s.addRule(w, r, "patterns_path", false, func(c *config.Config) string { return c.Common.PatternsPath })
+	default:
+		s.writeJSON(w, http.StatusMethodNotAllowed, errorJSON{Error: "method not allowed"})
+	}
+}
+
+// Filters - list loaded filters or add new one
+func (s *Server) Filters(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.writeJSON(w, http.StatusOK, s.Searcher.Filters())
+	case http.MethodPost:
+		s.addRule(w, r, "filters_path", true, func(c *config.Config) string { return c.Common.FiltresPath })
+	default:
+		s.writeJSON(w, http.StatusMethodNotAllowed, errorJSON{Error: "method not allowed"})
+	}
+}
+
+func (s *Server) addRule(w http.ResponseWriter, r *http.Request, option string, filter bool, getPath func(*config.Config) string) {
+	rule := config.Pattern{}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: fmt.Sprintf("can't parse request with: %v", err)})
+		return
+	}
+	if err := validateRule(rule, filter); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+
+	conf := s.Searcher.Config()
+	path := getPath(conf)
+	if path == "" {
+		s.writeJSON(w, http.StatusConflict, errorJSON{Error: fmt.Sprintf("%s is not configured", option)})
+		return
+	}
+
+	s.rulesMutex.Lock()
+	defer s.rulesMutex.Unlock()
+	file, err := findRulesFile(path)
+	if err != nil {
+		s.writeJSON(w, http.StatusConflict, errorJSON{Error: err.Error()})
+		return
+	}
+	previous, existed, err := appendRule(file, rule, filter)
+	if err != nil {
+		s.Log.Error().Str("error", err.Error()).Str("file", file).Msg("can't save rule")
+		s.writeJSON(w, http.StatusInternalServerError, errorJSON{Error: err.Error()})
+		return
+	}
+	// rule is applied before response, so next request sees it and errors of other rules are returned
+	if err := s.Searcher.Reload(conf); err != nil {
+		s.Log.Error().Str("error", err.Error()).Str("file", file).Msg("can't reload rules")
+		if restoreErr := restoreRules(file, previous, existed); restoreErr != nil {
+			s.Log.Error().Str("error", restoreErr.Error()).Str("file", file).Msg("can't restore rules")
+		}
+		s.writeJSON(w, http.StatusInternalServerError, errorJSON{Error: fmt.Sprintf("rule is not added: %v", err)})
+		return
+	}
+	s.Log.Info().Str("name", rule.Name).Str("file", file).Msg("rule added")
+	s.writeJSON(w, http.StatusCreated, rule)
+}
+
+// validateRule - pattern needs matcher and can't have scope, filter needs matcher or scope
+func validateRule(rule config.Pattern, filter bool) error {
+	if !filter {
+		return searcher.ValidatePattern(rule)
+	}
+	if rule.File == "" && rule.Content == "" && rule.Repo == "" && rule.Pattern == "" && rule.Commit == "" && rule.AuthorEmail == "" {
+		return fmt.Errorf("file, content, repo, pattern, commit or author_email is required")
+	}
+	return searcher.ValidateFilter(rule)
+}
+
+// findRulesFile - get file for saving rules which will be loaded by path glob,
+// it is api.yml in the first matched directory in sorted order if path has glob
+func findRulesFile(path string) (string, error) {
+	if !strings.ContainsAny(path, `*?[\`) {
+		return path, nil
+	}
+	dirs, err := filepath.Glob(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if f, err := os.Stat(dir); err != nil || !f.IsDir() {
+			continue
+		}
+		file := filepath.Join(dir, runtimeRulesFile)
+		if ok, _ := filepath.Match(path, file); ok {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("can't find directory for %s in '%s'", runtimeRulesFile, path)
+}
+
+// appendRule - add rule to file if all rules of file can be compiled,
+// previous content of file is returned for restoring it
+func appendRule(file string, rule config.Pattern, filter bool) ([]byte, bool, error) {
+	rules := []config.Pattern{}
+	previous, err := ioutil.ReadFile(file)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("can't read file '%s' with: %v", file, err)
+	}
+	if err := yaml.Unmarshal(previous, &rules); err != nil {
+		return nil, false, fmt.Errorf("can't parse file '%s' with: %v", file, err)
+	}
+	rules = append(rules, rule)
+	for _, r := range rules {
+		if err := validateRule(r, filter); err != nil {
+			return nil, false, fmt.Errorf("can't compile file '%s' with: %v", file, err)
+		}
+	}
+	rawData, err := yaml.Marshal(rules)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := helpers.WriteFileAtomic(file, rawData, 0644); err != nil {
+		return nil, false, fmt.Errorf("can't save file '%s' with: %v", file, err)
+	}
+	return previous, existed, nil
+}
+
+// restoreRules - return file to content before appendRule
+func restoreRules(file string, previous []byte, existed bool) error {
+	if !existed {
+		return os.Remove(file)
+	}
+	return helpers.WriteFileAtomic(file, previous, 0644)
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexAkulov/hungryfox/config"
+
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestFindRulesFile(t *testing.T) {
+	Convey("Test findRulesFile", t, func() {
+		dir, err := ioutil.TempDir("", "hungryfox")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		So(os.MkdirAll(filepath.Join(dir, "strong"), 0755), ShouldBeNil)
+		So(os.MkdirAll(filepath.Join(dir, "weak"), 0755), ShouldBeNil)
+
+		Convey("exact file", func() {
+			file, err := findRulesFile(filepath.Join(dir, "rules.yml"))
+			So(err, ShouldBeNil)
+			So(file, ShouldEqual, filepath.Join(dir, "rules.yml"))
+		})
+		Convey("glob in file name", func() {
+			file, err := findRulesFile(filepath.Join(dir, "*.yml"))
+			So(err, ShouldBeNil)
+			So(file, ShouldEqual, filepath.Join(dir, runtimeRulesFile))
+		})
+		Convey("glob in dir name", func() {
+			file, err := findRulesFile(filepath.Join(dir, "*", "*.yml"))
+			So(err, ShouldBeNil)
+			So(file, ShouldEqual, filepath.Join(dir, "strong", runtimeRulesFile))
+		})
+		Convey("file name doesn't match", func() {
+			_, err := findRulesFile(filepath.Join(dir, "*.yaml"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestAddRule(t *testing.T) {
+	Convey("Test add filter", t, func() {
+		dir, err := ioutil.TempDir("", "hungryfox")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		leakSearcher := &fakeSearcher{
+			config: &config.Config{Common: &config.Common{FiltresPath: filepath.Join(dir, "*.yml")}},
+		}
+		s := &Server{Searcher: leakSearcher, InsecureNoAuth: true, Log: zerolog.Nop()}
+		h := s.handler()
+
+		Convey("valid filter", func() {
+			for i := 0; i < 2; i++ {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/filters", strings.NewReader(`{"name":"test","file":"_test\\.go$"}`)))
+				So(w.Code, ShouldEqual, http.StatusCreated)
+			}
+			So(leakSearcher.updated, ShouldEqual, 2)
+			rawData, err := ioutil.ReadFile(filepath.Join(dir, runtimeRulesFile))
+			So(err, ShouldBeNil)
+			rules := []config.Pattern{}
+			So(yaml.Unmarshal(rawData, &rules), ShouldBeNil)
+			So(rules, ShouldResemble, []config.Pattern{
+				{Name: "test", File: `_test\.go$`},
+				{Name: "test", File: `_test\.go$`},
+			})
+		})
+		Convey("broken regexp", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/filters", strings.NewReader(`{"content":"(abc"}`)))
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(leakSearcher.updated, ShouldEqual, 0)
+		})
+		Convey("reload error", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/filters", strings.NewReader(`{"name":"first","file":"_test\\.go$"}`)))
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			saved, err := ioutil.ReadFile(filepath.Join(dir, runtimeRulesFile))
+			So(err, ShouldBeNil)
+
+			leakSearcher.reloadErr = fmt.Errorf("can't parse other.yml")
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/filters", strings.NewReader(`{"name":"second","file":"_test\\.go$"}`)))
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(w.Body.String(), ShouldContainSubstring, "can't parse other.yml")
+			rawData, err := ioutil.ReadFile(filepath.Join(dir, runtimeRulesFile))
+			So(err, ShouldBeNil)
+			So(string(rawData), ShouldEqual, string(saved))
+		})
+		Convey("new file is removed on reload error", func() {
+			leakSearcher.reloadErr = fmt.Errorf("can't parse other.yml")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/filters", strings.NewReader(`{"name":"test","file":"_test\\.go$"}`)))
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			_, err := os.Stat(filepath.Join(dir, runtimeRulesFile))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+		Convey("empty filter", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/filters", strings.NewReader(`{"name":"all"}`)))
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		})
+		Convey("scope is only for filters", func() {
+			for _, body := range []string{`{"repo":"https://github.com/foo/*"}`, `{"pattern":"AWS Access Key ID"}`, `{"content":"secret","commit":"abc"}`} {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/patterns", strings.NewReader(body)))
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/filters", strings.NewReader(`{"repo":"https://github.com/foo/*"}`)))
+			So(w.Code, ShouldEqual, http.StatusCreated)
+		})
+		Convey("patterns_path is not configured", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/patterns", strings.NewReader(`{"content":"secret"}`)))
+			So(w.Code, ShouldEqual, http.StatusConflict)
+		})
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ScanRequest - repo url or index of inspect entry in config for scan ahead of the others
+type ScanRequest struct {
+	RepoURL string `json:"repo_url,omitempty"`
+	Inspect *int   `json:"inspect,omitempty"`
+}
+
+type scanQueueJSON struct {
+	Added int      `json:"added"`
+	Queue []string `json:"queue"`
+}
+
+// EnqueueScan - add repo or all repos of inspect entry to scan queue
+func (s *Server) EnqueueScan(w http.ResponseWriter, r *http.Request) {
+	req := ScanRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: fmt.Sprintf("can't parse request with: %v", err)})
+		return
+	}
+	added := 0
+	switch {
+	case req.RepoURL != "" && req.Inspect != nil:
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: "only one of repo_url or inspect is allowed"})
+		return
+	case req.RepoURL != "":
+		if err := s.ScanManager.ScanRepoURL(req.RepoURL); err != nil {
+			s.writeJSON(w, http.StatusNotFound, errorJSON{Error: err.Error()})
+			return
+		}
+		added = 1
+	case req.Inspect != nil:
+		var err error
+		if added, err = s.ScanManager.ScanInspect(*req.Inspect); err != nil {
+			s.writeJSON(w, http.StatusNotFound, errorJSON{Error: err.Error()})
+			return
+		}
+	default:
+		s.writeJSON(w, http.StatusBadRequest, errorJSON{Error: "repo_url or inspect is required"})
+		return
+	}
+	s.writeJSON(w, http.StatusAccepted, scanQueueJSON{
+		Added: added,
+		Queue: s.ScanManager.Queue(),
+	})
+}
@@ -0,0 +1,383 @@
+package api
+
+import "net/http"
+
+// Index - embedded web dashboard
+func (s *Server) Index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		s.writeJSON(w, http.StatusNotFound, errorJSON{Error: "not found"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(indexHTML))
+}
+
+// all data is inserted with textContent, leaks may contain anything
+const indexHTML = `<!DOCTYPE html>
+<html>
+
+<head>
+  <title>HungryFox</title>
+  <meta http-equiv="Content-Type" content="text/html; charset=utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <style type="text/css">
+    body {
+      font-family: sans-serif;
+      font-size: 14px;
+      margin: 0;
+      background-color: #f4f4f4;
+      color: #111111;
+    }
+
+    header {
+      background-color: #FFA73B;
+      color: white;
+      padding: 10px 20px;
+      font-size: 20pt;
+    }
+
+    nav a {
+      color: rgb(216, 119, 0);
+      margin-right: 20px;
+      cursor: pointer;
+      font-size: 16px;
+    }
+
+    main {
+      padding: 10px 20px;
+    }
+
+    section {
+      background-color: #ffffff;
+      padding: 10px 20px;
+      margin-bottom: 20px;
+    }
+
+    table {
+      border-collapse: collapse;
+      width: 100%;
+    }
+
+    th,
+    td {
+      text-align: left;
+      padding: 4px 8px;
+      border-bottom: 1px solid #eeeeee;
+      vertical-align: top;
+    }
+
+    .code {
+      font-family: 'Courier New', monospace;
+      background-color: #f9f9f9;
+      white-space: pre-wrap;
+      word-break: break-all;
+    }
+
+    .failed {
+      color: #cc0000;
+    }
+
+    .progress {
+      background-color: #eeeeee;
+      height: 10px;
+      width: 300px;
+    }
+
+    .progress div {
+      background-color: #FFA73B;
+      height: 10px;
+    }
+
+    button {
+      margin: 2px;
+    }
+  </style>
+</head>
+
+<body>
+  <header>HungryFox</header>
+  <main>
+    <nav>
+      <a onclick="show('repos')">Repos</a>
+      <a onclick="show('leaks')">Leaks</a>
+      <a onclick="setToken()">Token</a>
+    </nav>
+    <section>
+      <h3>Current scan</h3>
+      <div id="scan">no scan</div>
+      <p id="queue"></p>
+    </section>
+    <section id="repos">
+      <h3>Repos (<span id="repos-count">0</span>)</h3>
+      <table>
+        <thead>
+          <tr>
+            <th>Repo</th>
+            <th>Last scan</th>
+            <th>Duration</th>
+            <th>Status</th>
+            <th>Leaks</th>
+            <th>Filtered</th>
+            <th></th>
+          </tr>
+        </thead>
+        <tbody id="repos-list"></tbody>
+      </table>
+    </section>
+    <section id="leaks" style="display: none">
+      <h3>Leaks (<span id="leaks-count">0</span>)</h3>
+      <p>
+        <select id="leaks-state" onchange="loadLeaks(0)">
+          <option value="open">open</option>
+          <option value="resolved">resolved</option>
+          <option value="false_positive">false positive</option>
+          <option value="accepted_risk">accepted risk</option>
+          <option value="">all</option>
+        </select>
+        <input id="leaks-repo" placeholder="repo url" onchange="loadLeaks(0)">
+        <input id="leaks-pattern" placeholder="pattern" onchange="loadLeaks(0)">
+        <select id="leaks-severity" onchange="loadLeaks(0)">
+          <option value="">any severity</option>
+          <option value="critical">critical</option>
+          <option value="high">high</option>
+          <option value="medium">medium</option>
+          <option value="low">low</option>
+        </select>
+        <button id="leaks-prev" onclick="loadLeaks(leaksOffset - pageSize)">&lt;</button>
+        <button id="leaks-next" onclick="loadLeaks(leaksOffset + pageSize)">&gt;</button>
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Leak</th>
+            <th>State</th>
+            <th></th>
+          </tr>
+        </thead>
+        <tbody id="leaks-list"></tbody>
+      </table>
+    </section>
+  </main>
+  <script>
+    var pageSize = 50;
+    var leaksOffset = 0;
+
+    function el(tag, text, className) {
+      var e = document.createElement(tag);
+      if (text !== undefined && text !== null) {
+        e.textContent = text;
+      }
+      if (className) {
+        e.className = className;
+      }
+      return e;
+    }
+
+    function link(href, text) {
+      var a = el('a', text);
+      if (/^https?:\/\//.test(href)) {
+        a.href = href;
+      }
+      return a;
+    }
+
+    function row(cells) {
+      var tr = el('tr');
+      cells.forEach(function (cell) {
+        var td = el('td');
+        if (cell instanceof Node) {
+          td.appendChild(cell);
+        } else {
+          td.textContent = cell;
+        }
+        tr.appendChild(td);
+      });
+      return tr;
+    }
+
+    function formatTime(ts) {
+      if (!ts || ts.indexOf('0001-') === 0) {
+        return 'never';
+      }
+      return new Date(ts).toLocaleString();
+    }
+
+    function formatDuration(start, end) {
+      if (!start || start.indexOf('0001-') === 0 || !end || end.indexOf('0001-') === 0) {
+        return '';
+      }
+      var seconds = Math.round((new Date(end) - new Date(start)) / 1000);
+      if (seconds < 0) {
+        return '';
+      }
+      return Math.floor(seconds / 60) + 'm' + (seconds % 60) + 's';
+    }
+
+    function request(method, url, body) {
+      var options = { method: method, headers: {} };
+      var token = localStorage.getItem('hungryfox-token');
+      if (token) {
+        options.headers['Authorization'] = 'Bearer ' + token;
+      }
+      if (body) {
+        options.body = JSON.stringify(body);
+        options.headers['Content-Type'] = 'application/json';
+      }
+      return fetch(url, options).then(function (resp) {
+        return resp.json().then(function (data) {
+          if (!resp.ok) {
+            throw new Error(data.error || resp.statusText);
+          }
+          return data;
+        });
+      });
+    }
+
+    function setToken() {
+      var token = prompt('API token', localStorage.getItem('hungryfox-token') || '');
+      if (token === null) {
+        return;
+      }
+      localStorage.setItem('hungryfox-token', token);
+      loadStatus();
+      loadRepos();
+    }
+
+    function show(id) {
+      document.getElementById('repos').style.display = id === 'repos' ? '' : 'none';
+      document.getElementById('leaks').style.display = id === 'leaks' ? '' : 'none';
+      if (id === 'leaks') {
+        loadLeaks(leaksOffset);
+      }
+    }
+
+    function loadStatus() {
+      request('GET', 'api/v1/status').then(function (status) {
+        var scan = document.getElementById('scan');
+        scan.textContent = '';
+        if (status.current_scan) {
+          scan.appendChild(link(status.current_scan.repo.url, status.current_scan.repo.url));
+          scan.appendChild(el('span', ' for ' + status.current_scan.duration +
+            ', leaks: ' + status.current_scan.repo.leaks_found));
+          if (status.current_scan.progress >= 0) {
+            var bar = el('div', null, 'progress');
+            var value = el('div');
+            value.style.width = status.current_scan.progress + '%';
+            bar.appendChild(value);
+            scan.appendChild(bar);
+          }
+        } else {
+          scan.textContent = 'no scan';
+        }
+        var queue = status.queue || [];
+        document.getElementById('queue').textContent = queue.length ? 'Queue: ' + queue.join(', ') : '';
+      }).catch(function (err) {
+        document.getElementById('scan').textContent = 'error: ' + err.message;
+      });
+    }
+
+    function loadRepos() {
+      request('GET', 'api/v1/repos').then(function (repos) {
+        var list = document.getElementById('repos-list');
+        list.textContent = '';
+        document.getElementById('repos-count').textContent = repos.length;
+        repos.forEach(function (repo) {
+          var status = el('span', repo.scan.success ? 'ok' : 'failed', repo.scan.success ? '' : 'failed');
+          if (repo.scan.start_time.indexOf('0001-') === 0) {
+            status = el('span', 'not scanned');
+          }
+          var scanButton = el('button', 'scan now');
+          scanButton.onclick = function () {
+            request('POST', 'api/v1/scan', { repo_url: repo.url }).then(loadStatus).catch(function (err) {
+              alert(err.message);
+            });
+          };
+          list.appendChild(row([
+            link(repo.url, repo.url),
+            formatTime(repo.scan.end_time),
+            formatDuration(repo.scan.start_time, repo.scan.end_time),
+            status,
+            repo.leaks_found,
+            repo.leaks_filtred,
+            scanButton
+          ]));
+        });
+      }).catch(function (err) {
+        document.getElementById('repos-list').textContent = 'error: ' + err.message;
+      });
+    }
+
+    function setState(id, state) {
+      var comment = prompt('Comment', '');
+      if (comment === null) {
+        return;
+      }
+      request('POST', 'api/v1/leaks/' + encodeURIComponent(id) + '/state', {
+        state: state,
+        comment: comment
+      }).then(function () {
+        loadLeaks(leaksOffset);
+      }).catch(function (err) {
+        alert(err.message);
+      });
+    }
+
+    function loadLeaks(offset) {
+      leaksOffset = Math.max(offset, 0);
+      var query = 'offset=' + leaksOffset + '&limit=' + pageSize +
+        '&state=' + encodeURIComponent(document.getElementById('leaks-state').value) +
+        '&repo_url=' + encodeURIComponent(document.getElementById('leaks-repo').value) +
+        '&pattern=' + encodeURIComponent(document.getElementById('leaks-pattern').value) +
+        '&severity=' + encodeURIComponent(document.getElementById('leaks-severity').value);
+      request('GET', 'api/v1/leaks?' + query).then(function (result) {
+        var list = document.getElementById('leaks-list');
+        list.textContent = '';
+        document.getElementById('leaks-count').textContent = result.total;
+        document.getElementById('leaks-prev').disabled = leaksOffset === 0;
+        document.getElementById('leaks-next').disabled = leaksOffset + pageSize >= result.total;
+        result.items.forEach(function (record) {
+          var leak = record.leak;
+          var info = el('div');
+          var line = leak.line ? '#L' + leak.line + (leak.line_end ? '-L' + leak.line_end : '') : '';
+          info.appendChild(link(leak.repo_url + '/blob/' + leak.commit + '/' + leak.filepath + line,
+            leak.repo_url + ' ' + leak.filepath + (leak.line ? ':' + leak.line + (leak.line_end ? '-' + leak.line_end : '') : '')));
+          info.appendChild(el('div', (leak.severity ? leak.severity + ': ' : '') + leak.pattern_name + (leak.encoding ? ' (' + leak.encoding + ' encoded)' : ''), leak.severity === 'critical' || leak.severity === 'high' ? 'failed' : ''));
+          info.appendChild(el('div', leak.leak, 'code'));
+          info.appendChild(el('div', 'Commit ' + leak.commit + ' by ' + leak.author + ' <' + leak.email + '> ' + formatTime(leak.ts)));
+          (record.locations || []).forEach(function (location) {
+            info.appendChild(el('div', 'Also in ' + location.repo_url + ' ' + location.filepath +
+              (location.line ? ':' + location.line : '') + ' commit ' + location.commit));
+          });
+
+          var state = el('div', record.state);
+          if (record.changed_by || record.comment) {
+            state.appendChild(el('div', [record.changed_by, record.comment].filter(Boolean).join(': ')));
+          }
+
+          var buttons = el('div');
+          [['resolved', 'resolve'], ['false_positive', 'false positive'], ['accepted_risk', 'accept risk'], ['open', 'reopen']].forEach(function (b) {
+            if (b[0] === record.state) {
+              return;
+            }
+            var button = el('button', b[1]);
+            button.onclick = function () {
+              setState(leak.id, b[0]);
+            };
+            buttons.appendChild(button);
+          });
+          list.appendChild(row([info, state, buttons]));
+        });
+      }).catch(function (err) {
+        document.getElementById('leaks-list').textContent = 'error: ' + err.message;
+      });
+    }
+
+    loadStatus();
+    loadRepos();
+    setInterval(loadStatus, 5000);
+    setInterval(loadRepos, 30000);
+  </script>
+</body>
+
+</html>
+`
@@ -12,12 +12,16 @@ import (
 	"time"
 
 	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/api"
 	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/events"
 	"github.com/AlexAkulov/hungryfox/helpers"
+	"github.com/AlexAkulov/hungryfox/metrics"
 	"github.com/AlexAkulov/hungryfox/router"
 	"github.com/AlexAkulov/hungryfox/scanmanager"
 	"github.com/AlexAkulov/hungryfox/searcher"
 	"github.com/AlexAkulov/hungryfox/state/filestate"
+	"github.com/AlexAkulov/hungryfox/state/leakstate"
 
 	"github.com/rs/zerolog"
 )
@@ -28,6 +32,9 @@ var (
 	configFlag      = flag.String("config", "config.yml", "config file location")
 	pprofFlag       = flag.Bool("pprof", false, "Enable listen pprof on :6060")
 	printConfigFlag = flag.Bool("default-config", false, "Print default config to stdout and exit")
+	scanFlag        = flag.String("scan", "", "Ask running hungryfox to scan repo with this url ahead of the others and exit")
+	tokenFlag       = flag.String("token", os.Getenv("HUNGRYFOX_TOKEN"), "API token with 'scan' scope for -scan and -scan-inspect")
+	scanInspectFlag = flag.Int("scan-inspect", -1, "Ask running hungryfox to scan all repos of inspect entry with this index ahead of the others and exit")
 )
 
 func main() {
@@ -44,6 +51,31 @@ func main() {
 		os.Exit(1)
 	}
 
+	if flag.Arg(0) == "patterns" {
+		if flag.Arg(1) != "test" {
+			fmt.Fprintf(os.Stderr, "unknown command 'patterns %s', only 'patterns test' is supported\n", flag.Arg(1))
+			os.Exit(1)
+		}
+		if !testPatterns(conf, os.Stdout) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *scanFlag != "" || *scanInspectFlag >= 0 {
+		req := api.ScanRequest{RepoURL: *scanFlag}
+		if *scanInspectFlag >= 0 {
+			req.Inspect = scanInspectFlag
+		}
+		result, err := requestScan(conf.Common.APIListen, *tokenFlag, req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't request scan: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(result)
+		os.Exit(0)
+	}
+
 	var lvl zerolog.Level
 	switch conf.Common.LogLevel {
 	case "debug":
@@ -63,6 +95,8 @@ func main() {
 
 	diffChannel := make(chan *hungryfox.Diff, 100)
 	leakChannel := make(chan *hungryfox.Leak, 1)
+	metrics.RegisterQueue("diffs", func() int { return len(diffChannel) })
+	metrics.RegisterQueue("leaks", func() int { return len(leakChannel) })
 
 	if *skipScan {
 		stateManager := &filestate.StateManager{
@@ -86,9 +120,25 @@ func main() {
 		os.Exit(0)
 	}
 
+	logger.Debug().Str("service", "leak store").Msg("start")
+	leakStore := &leakstate.Store{
+		Location:    conf.Common.LeaksDBFile,
+		DedupByPath: conf.Common.DedupByPath,
+		Log:         logger,
+	}
+	if err := leakStore.Start(); err != nil {
+		logger.Error().Str("service", "leak store").Str("error", err.Error()).Msg("fail")
+		os.Exit(1)
+	}
+	logger.Debug().Str("service", "leak store").Msg("started")
+
+	eventBroker := &events.Broker{}
+
 	logger.Debug().Str("service", "leaks router").Msg("start")
 	leakRouter := &router.LeaksRouter{
 		LeakChannel: leakChannel,
+		LeakStore:   leakStore,
+		Events:      eventBroker,
 		Config:      conf,
 		Log:         logger,
 	}
@@ -134,6 +184,8 @@ func main() {
 		DiffChannel:  diffChannel,
 		Log:          logger,
 		StateManager: stateManager,
+		Events:       eventBroker,
+		RepoConfigs:  leakSearcher,
 	}
 	if err := scanManager.Start(conf); err != nil {
 		logger.Error().Str("service", "scan manager").Str("error", err.Error()).Msg("fail")
@@ -141,6 +193,28 @@ func main() {
 	}
 	logger.Debug().Str("service", "scan manager").Msg("started")
 
+	var apiServer *api.Server
+	if conf.Common.APIListen != "" {
+		logger.Debug().Str("service", "api").Msg("start")
+		apiServer = &api.Server{
+			Listen:         conf.Common.APIListen,
+			ScanManager:    scanManager,
+			Searcher:       leakSearcher,
+			LeakStore:      leakStore,
+			Events:         eventBroker,
+			Tokens:         conf.API.Tokens,
+			AuditLog:       conf.API.AuditLog,
+			InsecureNoAuth: conf.API.InsecureNoAuth,
+			Redaction:      hungryfox.Redaction(conf.API.Redaction),
+			Log:            logger,
+		}
+		if err := apiServer.Start(); err != nil {
+			logger.Error().Str("service", "api").Str("error", err.Error()).Msg("fail")
+			os.Exit(1)
+		}
+		logger.Debug().Str("service", "api").Str("listen", conf.Common.APIListen).Msg("started")
+	}
+
 	statusTicker := time.NewTicker(time.Second * 10)
 	defer statusTicker.Stop()
 	go func() {
@@ -183,6 +257,13 @@ func main() {
 		logger.Info().Msg("settings reloaded")
 	}
 
+	if apiServer != nil {
+		if err := apiServer.Stop(); err != nil {
+			logger.Error().Str("error", err.Error()).Str("service", "api").Msg("can't stop")
+		}
+		logger.Debug().Str("service", "api").Msg("stopped")
+	}
+
 	if err := scanManager.Stop(); err != nil {
 		logger.Error().Str("error", err.Error()).Str("service", "scan manager").Msg("can't stop")
 	}
@@ -198,6 +279,11 @@ func main() {
 	}
 	logger.Debug().Str("service", "leaks router").Msg("stopped")
 
+	if err := leakStore.Stop(); err != nil {
+		logger.Error().Str("error", err.Error()).Str("service", "leak store").Msg("can't stop")
+	}
+	logger.Debug().Str("service", "leak store").Msg("stopped")
+
 	logger.Debug().Str("service", "state manager").Msg("stop")
 	if err := stateManager.Stop(); err != nil {
 		logger.Error().Str("error", err.Error()).Str("service", "state manager").Msg("can't stop")
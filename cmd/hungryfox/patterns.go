@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/searcher"
+)
+
+// testPatterns - check examples and counterexamples of all patterns and filters, return false if there are problems
+func testPatterns(conf *config.Config, out io.Writer) bool {
+	report := searcher.CheckConfig(conf)
+	for _, problem := range report.Problems {
+		fmt.Fprintln(out, problem.String())
+	}
+	for _, pattern := range report.WithoutExamples {
+		fmt.Fprintln(out, pattern.String())
+	}
+	fmt.Fprintf(out, "%d patterns checked, %d without examples, %d problems\n", report.Patterns, len(report.WithoutExamples), len(report.Problems))
+	return len(report.Problems) == 0
+}
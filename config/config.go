@@ -15,6 +15,9 @@ type WebHook struct {
 	Method  string            `yaml:"method"`
 	URL     string            `yaml:"url"`
 	Headers map[string]string `yaml:"headers"`
+	// Redaction - full, masked or hash
+	Redaction   string `yaml:"redaction"`
+	MinSeverity string `yaml:"min_severity"`
 }
 
 type SMTP struct {
@@ -28,10 +31,29 @@ type SMTP struct {
 	Recipient    string `yaml:"recipient"`
 	SentToAuthor bool   `yaml:"sent_to_autor"`
 	Delay        string `yaml:"delay"`
+	Redaction    string `yaml:"redaction"`
+	MinSeverity  string `yaml:"min_severity"`
+}
+
+type APIToken struct {
+	Name   string   `yaml:"name"`
+	Token  string   `yaml:"token"`
+	Scopes []string `yaml:"scopes"`
+}
+
+type API struct {
+	TokensFile string     `yaml:"tokens_file"`
+	Tokens     []APIToken `yaml:"tokens"`
+	AuditLog   string     `yaml:"audit_log"`
+	// InsecureNoAuth - allow API without tokens, only on loopback address
+	InsecureNoAuth bool `yaml:"insecure_no_auth"`
+	// Redaction - full, masked or hash of leaks in API responses and events stream, masked by default
+	Redaction string `yaml:"redaction"`
 }
 
 type Config struct {
 	Common   *Common   `yaml:"common"`
+	API      *API      `yaml:"api"`
 	Inspect  []Inspect `yaml:"inspect"`
 	Patterns []Pattern `yaml:"patterns"`
 	Filters  []Pattern `yaml:"filters"`
@@ -57,22 +79,77 @@ type Common struct {
 	HistoryPastLimitString string `yaml:"history_limit"`
 	LogLevel               string `yaml:"log_level"`
 	LeaksFile              string `yaml:"leaks_file"`
+	LeaksFileRedaction     string `yaml:"leaks_file_redaction"`
+	ContextLines           int    `yaml:"context_lines"`
+	DecodeMinLength        int    `yaml:"decode_min_length"`
+	LeaksDBFile            string `yaml:"leaks_db_file"`
+	DedupByPath            bool   `yaml:"dedup_by_path"`
 	ScanIntervalString     string `yaml:"scan_interval"`
 	PatternsPath           string `yaml:"patterns_path"`
 	FiltresPath            string `yaml:"filters_path"`
+	KnownSecretsPath       string `yaml:"known_secrets_path"`
+	DisableRepoConfig      bool   `yaml:"disable_repo_config"`
+	ScanBinary             bool   `yaml:"scan_binary"`
 	Workers                int    `yaml:"workers"`
+	APIListen              string `yaml:"api_listen"`
 	HistoryPastLimit       time.Time
 	ScanInterval           time.Duration
 }
 
 type Pattern struct {
-	Name    string `yaml:"name"`
-	File    string `yaml:"file"`
-	Content string `yaml:"content"`
+	Name string `yaml:"name,omitempty" json:"name"`
+	File string `yaml:"file,omitempty" json:"file"`
+	// ExcludeFile - regexp of files which are not checked by pattern
+	ExcludeFile string   `yaml:"exclude_file,omitempty" json:"exclude_file,omitempty"`
+	Content     string   `yaml:"content,omitempty" json:"content"`
+	Key         string   `yaml:"key,omitempty" json:"key,omitempty"`
+	Entropy     *Entropy `yaml:"entropy,omitempty" json:"entropy,omitempty"`
+	Keywords    []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+	// Multiline - content regexp is matched with whole chunk instead of each line
+	Multiline bool `yaml:"multiline,omitempty" json:"multiline,omitempty"`
+	// KeyName and Value - regexps of key and value in YAML, JSON, .env, properties, INI and tfvars files,
+	// placeholders like ${VAR} and changeme are skipped
+	KeyName string `yaml:"key_name,omitempty" json:"key_name,omitempty"`
+	Value   string `yaml:"value,omitempty" json:"value,omitempty"`
+	// Severity - critical, high, medium or low
+	Severity    string   `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Tags        []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	// Remediation - URL of instruction how to fix leak
+	Remediation string `yaml:"remediation,omitempty" json:"remediation,omitempty"`
+	// Strong - leaks of pattern can't be filtered or excluded by .hungryfox.yml of repo
+	Strong bool `yaml:"strong,omitempty" json:"strong,omitempty"`
+	// Examples must be matched by pattern and Counterexamples must not, they are checked by 'patterns test' command
+	Examples        []string `yaml:"examples,omitempty" json:"examples,omitempty"`
+	Counterexamples []string `yaml:"counterexamples,omitempty" json:"counterexamples,omitempty"`
+
+	// Scope of filter, all of them are optional
+	// Repo - glob of repo URL
+	Repo string `yaml:"repo,omitempty" json:"repo,omitempty"`
+	// Pattern - name of pattern which found leak
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	// Commit - prefix of commit hash
+	Commit string `yaml:"commit,omitempty" json:"commit,omitempty"`
+	// AuthorEmail - glob of commit author email, case insensitive
+	AuthorEmail string `yaml:"author_email,omitempty" json:"author_email,omitempty"`
+	// Expires - date (2006-01-02) or time (RFC3339) since which filter isn't applied
+	Expires string `yaml:"expires,omitempty" json:"expires,omitempty"`
+}
+
+// Entropy - search random-looking tokens
+type Entropy struct {
+	Charset    string  `yaml:"charset" json:"charset"`
+	MinLength  int     `yaml:"min_length,omitempty" json:"min_length,omitempty"`
+	MinEntropy float64 `yaml:"min_entropy,omitempty" json:"min_entropy,omitempty"`
 }
 
 func defaultConfig() *Config {
 	return &Config{
+		Common: &Common{
+			ContextLines:    2,
+			DecodeMinLength: 20,
+		},
+		API: &API{},
 		SMTP: &SMTP{
 			Delay: "5m",
 		},
@@ -101,9 +178,31 @@ func LoadConfig(configLocation string) (*Config, error) {
 	if config.Common.ScanInterval < time.Second {
 		return nil, fmt.Errorf("scan_interval so small")
 	}
+	if config.API == nil {
+		config.API = &API{}
+	}
+	if config.API.TokensFile != "" {
+		tokens, err := loadTokens(config.API.TokensFile)
+		if err != nil {
+			return nil, err
+		}
+		config.API.Tokens = append(config.API.Tokens, tokens...)
+	}
 	return config, nil
 }
 
+func loadTokens(file string) ([]APIToken, error) {
+	tokens := []APIToken{}
+	rawData, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read tokens file with: %v", err)
+	}
+	if err := yaml.Unmarshal(rawData, &tokens); err != nil {
+		return nil, fmt.Errorf("can't parse tokens file with: %v", err)
+	}
+	return tokens, nil
+}
+
 func PrintDefaultConfig() {
 	c := defaultConfig()
 	d, _ := yaml.Marshal(&c)
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RepoConfigFile - name of file with settings of scanned repo in its root
+const RepoConfigFile = ".hungryfox.yml"
+
+// RepoConfig - settings of scanned repo from its .hungryfox.yml, they never affect strong patterns
+type RepoConfig struct {
+	// Filters - the same as global filters, but only for this repo
+	Filters []Pattern `yaml:"filters"`
+	// Exclude - globs of file paths or directories which are not scanned
+	Exclude []string `yaml:"exclude"`
+	// Owners - contacts of repo owners which are added to leaks
+	Owners []string `yaml:"owners"`
+}
+
+// ParseRepoConfig - parse content of .hungryfox.yml
+func ParseRepoConfig(rawData []byte) (*RepoConfig, error) {
+	repoConfig := &RepoConfig{}
+	if err := yaml.UnmarshalStrict(rawData, repoConfig); err != nil {
+		return nil, fmt.Errorf("can't parse %s with: %v", RepoConfigFile, err)
+	}
+	return repoConfig, nil
+}
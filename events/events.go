@@ -0,0 +1,75 @@
+package events
+
+import (
+	"time"
+
+	sync "github.com/sasha-s/go-deadlock"
+)
+
+const (
+	Leak         = "leak"
+	ScanStarted  = "scan_started"
+	ScanFinished = "scan_finished"
+)
+
+// subscriber gets only this number of unread events, the rest are dropped
+const subscriberBufferSize = 100
+
+type Event struct {
+	Type      string      `json:"type"`
+	TimeStamp time.Time   `json:"ts"`
+	Data      interface{} `json:"data"`
+}
+
+type ScanData struct {
+	RepoURL   string    `json:"repo_url"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time,omitempty"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Broker - delivers events to all subscribers. Nil broker drops all events
+type Broker struct {
+	subscribers map[chan Event]struct{}
+	mutex       sync.RWMutex
+}
+
+// Publish - send event to all subscribers without blocking
+func (b *Broker) Publish(eventType string, data interface{}) {
+	if b == nil {
+		return
+	}
+	e := Event{
+		Type:      eventType,
+		TimeStamp: time.Now().UTC(),
+		Data:      data,
+	}
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe - get channel with new events
+func (b *Broker) Subscribe() chan Event {
+	ch := make(chan Event, subscriberBufferSize)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = map[chan Event]struct{}{}
+	}
+	b.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// Unsubscribe - stop sending events to channel
+func (b *Broker) Unsubscribe(ch chan Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.subscribers, ch)
+}
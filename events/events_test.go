@@ -0,0 +1,39 @@
+package events
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBroker(t *testing.T) {
+	Convey("Test broker", t, func() {
+		b := &Broker{}
+		ch1 := b.Subscribe()
+		ch2 := b.Subscribe()
+		b.Publish(Leak, "data")
+		e := <-ch1
+		So(e.Type, ShouldEqual, Leak)
+		So(e.Data, ShouldEqual, "data")
+		So((<-ch2).Type, ShouldEqual, Leak)
+
+		Convey("slow subscriber doesn't block", func() {
+			for i := 0; i < subscriberBufferSize*2; i++ {
+				b.Publish(ScanStarted, i)
+			}
+			So(len(ch1), ShouldEqual, subscriberBufferSize)
+		})
+
+		Convey("unsubscribed channel gets nothing", func() {
+			b.Unsubscribe(ch1)
+			b.Publish(ScanFinished, nil)
+			So(len(ch1), ShouldEqual, 0)
+			So(len(ch2), ShouldEqual, 1)
+		})
+
+		Convey("nil broker", func() {
+			var nilBroker *Broker
+			So(func() { nilBroker.Publish(Leak, nil) }, ShouldNotPanic)
+		})
+	})
+}
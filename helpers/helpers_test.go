@@ -1,6 +1,9 @@
 package helpers
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -39,3 +42,23 @@ func TestParseDuration(t *testing.T) {
 		So(result, ShouldEqual, time.Duration(time.Hour*3+time.Minute*2+time.Second))
 	})
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	Convey("file is replaced and temporary file is removed", t, func() {
+		dir, err := ioutil.TempDir("", "hungryfox")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "data.json")
+		So(ioutil.WriteFile(file, []byte("old"), 0644), ShouldBeNil)
+		So(WriteFileAtomic(file, []byte("new"), 0600), ShouldBeNil)
+		rawData, err := ioutil.ReadFile(file)
+		So(err, ShouldBeNil)
+		So(string(rawData), ShouldEqual, "new")
+		info, err := os.Stat(file)
+		So(err, ShouldBeNil)
+		So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+		files, err := ioutil.ReadDir(dir)
+		So(err, ShouldBeNil)
+		So(files, ShouldHaveLength, 1)
+	})
+}
@@ -0,0 +1,171 @@
+package repo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+const (
+	// maxBinarySize - limit of binary file or archive entry which is extracted
+	maxBinarySize = 10 << 20
+	// maxExtractedSize - limit of all extracted data of one binary file
+	maxExtractedSize = 100 << 20
+	// maxArchiveDepth - limit of nested archives like jar in war
+	maxArchiveDepth = 3
+	// minStringLength - minimal length of printable string extracted from compiled files
+	minStringLength = 6
+)
+
+// extensions of compiled files which have printable strings
+var stringsExtensions = map[string]bool{
+	".pyc":   true,
+	".class": true,
+}
+
+// extensions of PKCS#12 keystores which have no reliable magic number
+var pkcs12Extensions = map[string]bool{
+	".pfx": true,
+	".p12": true,
+}
+
+// extractedFile - text file from archive or text extracted from binary file
+type extractedFile struct {
+	// Path - path of binary file, files in archives are like archive!inner/path
+	Path    string
+	Content string
+	// Text - content is text file, so line numbers have sense
+	Text bool
+}
+
+type extractor struct {
+	remaining int
+}
+
+// extractBinary - text files from zip, jar, war and tar.gz archives, detection of keystores
+// and strings of compiled files
+func extractBinary(filePath string, data []byte) []extractedFile {
+	e := &extractor{remaining: maxExtractedSize}
+	return e.extract(filePath, data, 1)
+}
+
+func (e *extractor) extract(filePath string, data []byte, depth int) []extractedFile {
+	ext := strings.ToLower(path.Ext(filePath))
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		if depth > maxArchiveDepth {
+			return nil
+		}
+		return e.extractZip(filePath, data, depth)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		if depth > maxArchiveDepth {
+			return nil
+		}
+		return e.extractTarGz(filePath, data, depth)
+	case bytes.HasPrefix(data, []byte{0xfe, 0xed, 0xfe, 0xed}), bytes.HasPrefix(data, []byte{0xce, 0xce, 0xce, 0xce}):
+		return []extractedFile{{Path: filePath, Content: "Java keystore"}}
+	case pkcs12Extensions[ext] && len(data) > 0 && data[0] == 0x30:
+		return []extractedFile{{Path: filePath, Content: "PKCS#12 keystore"}}
+	case stringsExtensions[ext]:
+		if content := printableStrings(data, minStringLength); content != "" {
+			return []extractedFile{{Path: filePath, Content: content}}
+		}
+	}
+	return nil
+}
+
+// entry - text file or nested binary file of archive
+func (e *extractor) entry(archivePath, name string, r io.Reader, depth int) []extractedFile {
+	if e.remaining <= 0 {
+		return nil
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(minInt(maxBinarySize, e.remaining))+1))
+	if err != nil || len(data) > maxBinarySize || len(data) > e.remaining {
+		return nil
+	}
+	e.remaining -= len(data)
+	innerPath := archivePath + "!" + name
+	if isText(data) {
+		return []extractedFile{{Path: innerPath, Content: string(data), Text: true}}
+	}
+	return e.extract(innerPath, data, depth+1)
+}
+
+func (e *extractor) extractZip(filePath string, data []byte, depth int) []extractedFile {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	result := []extractedFile{}
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		result = append(result, e.entry(filePath, f.Name, rc, depth)...)
+		rc.Close()
+	}
+	return result
+}
+
+func (e *extractor) extractTarGz(filePath string, data []byte, depth int) []extractedFile {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	defer gz.Close()
+	result := []extractedFile{}
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			return result
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		result = append(result, e.entry(filePath, header.Name, reader, depth)...)
+	}
+}
+
+// isText - there is no zero byte in the beginning of data like git checks it
+func isText(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) < 0
+}
+
+// printableStrings - lines of printable ASCII strings not shorter than minLength like strings utility does
+func printableStrings(data []byte, minLength int) string {
+	result := []string{}
+	start := -1
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && (data[i] >= 0x20 && data[i] < 0x7f || data[i] == '\t') {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minLength {
+			result = append(result, string(data[start:i]))
+		}
+		start = -1
+	}
+	return strings.Join(result, "\n")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -10,6 +11,8 @@ import (
 	"time"
 
 	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/metrics"
 
 	"gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
@@ -25,15 +28,21 @@ type Repo struct {
 	CloneURL         string
 	URL              string
 	AllowUpdate      bool
+	ScanBinary       bool
 	repository       *git.Repository
 	scannedHash      map[string]struct{}
 	commitsTotal     int
 	commitsScanned   int
+	repoConfig       *config.RepoConfig
+	repoConfigErr    error
 }
 
+// maxRepoConfigSize - limit of .hungryfox.yml size
+const maxRepoConfigSize = 1 << 20
+
 func (r *Repo) GetProgress() int {
 	if r.commitsTotal > 0 {
-		return (r.commitsScanned / r.commitsTotal) * 1000
+		return r.commitsScanned * 100 / r.commitsTotal
 	}
 	return -1
 }
@@ -156,6 +165,7 @@ func (r *Repo) Scan() error {
 	}
 	for i, commit := range commits {
 		r.commitsScanned = i + 1
+		metrics.CommitsScanned.Inc()
 		if commit.Committer.When.Before(r.HistoryPastLimit) {
 			r.getAllChanges(commit, false)
 			break
@@ -178,30 +188,30 @@ func (r *Repo) getAllChanges(commit *object.Commit, initCommit bool) error {
 	if err != nil {
 		return err
 	}
+	// TODO: Use blame for this
+	author := "unknown"
+	authorEmail := "unknown"
+	if initCommit {
+		author = commit.Author.Name
+		authorEmail = commit.Author.Email
+	}
 	for _, p := range patch.FilePatches() {
 		_, f := p.Files()
-		if f == nil || p.IsBinary() {
+		if f == nil {
 			continue
 		}
-		for _, chunk := range p.Chunks() {
-			if chunk.Type() != diff.Add {
-				continue
-			}
-			// TODO: Use blame for this
-			author := "unknown"
-			authorEmail := "unknown"
-
-			if initCommit {
-				author = commit.Author.Name
-				authorEmail = commit.Author.Email
-			}
+		if p.IsBinary() {
+			r.sendBinary(commit, f, author, authorEmail)
+			continue
+		}
+		for _, chunk := range addedChunks(p) {
 			r.DiffChannel <- &hungryfox.Diff{
 				CommitHash:  commit.Hash.String(),
 				RepoURL:     r.URL,
 				RepoPath:    r.RepoPath,
 				FilePath:    f.Path(),
-				LineBegin:   0, // TODO: await https://github.com/src-d/go-git/issues/806
-				Content:     chunk.Content(),
+				LineBegin:   chunk.LineBegin,
+				Content:     chunk.Content,
 				Author:      author,
 				AuthorEmail: authorEmail,
 				TimeStamp:   commit.Author.When,
@@ -230,20 +240,21 @@ func (r *Repo) getCommitChanges(commit *object.Commit) error {
 	}
 	for _, p := range patch.FilePatches() {
 		_, f := p.Files()
-		if f == nil || p.IsBinary() {
+		if f == nil {
 			continue
 		}
-		for _, chunk := range p.Chunks() {
-			if chunk.Type() != diff.Add {
-				continue
-			}
+		if p.IsBinary() {
+			r.sendBinary(commit, f, commit.Author.Name, commit.Author.Email)
+			continue
+		}
+		for _, chunk := range addedChunks(p) {
 			r.DiffChannel <- &hungryfox.Diff{
 				CommitHash:  commit.Hash.String(),
 				RepoURL:     r.URL,
 				RepoPath:    r.RepoPath,
 				FilePath:    f.Path(),
-				LineBegin:   0, // TODO: await https://github.com/src-d/go-git/issues/806
-				Content:     chunk.Content(),
+				LineBegin:   chunk.LineBegin,
+				Content:     chunk.Content,
 				Author:      commit.Author.Name,
 				AuthorEmail: commit.Author.Email,
 				TimeStamp:   commit.Author.When,
@@ -253,11 +264,121 @@ func (r *Repo) getCommitChanges(commit *object.Commit) error {
 	return nil
 }
 
+// sendBinary - send text files from archive or text extracted from binary file
+func (r *Repo) sendBinary(commit *object.Commit, f diff.File, author, authorEmail string) {
+	if !r.ScanBinary {
+		return
+	}
+	blob, err := r.repository.BlobObject(f.Hash())
+	if err != nil || blob.Size > maxBinarySize {
+		return
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return
+	}
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return
+	}
+	for _, file := range extractBinary(f.Path(), data) {
+		lineBegin := 0
+		if file.Text {
+			lineBegin = 1
+		}
+		r.DiffChannel <- &hungryfox.Diff{
+			CommitHash:  commit.Hash.String(),
+			RepoURL:     r.URL,
+			RepoPath:    r.RepoPath,
+			FilePath:    file.Path,
+			LineBegin:   lineBegin,
+			Content:     file.Content,
+			Author:      author,
+			AuthorEmail: authorEmail,
+			TimeStamp:   commit.Author.When,
+		}
+	}
+}
+
+type addedChunk struct {
+	LineBegin int
+	Content   string
+}
+
+// addedChunks - added parts of file with number of their first line in new file
+func addedChunks(p diff.FilePatch) []addedChunk {
+	result := []addedChunk{}
+	line := 1
+	for _, chunk := range p.Chunks() {
+		content := chunk.Content()
+		switch chunk.Type() {
+		case diff.Add:
+			result = append(result, addedChunk{LineBegin: line, Content: content})
+		case diff.Delete:
+			continue
+		}
+		line += countLines(content)
+	}
+	return result
+}
+
+func countLines(content string) int {
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
+
 func (r *Repo) fullRepoPath() string {
 	return filepath.Join(r.DataPath, r.RepoPath)
 }
 
+// Open - open repo, clone or fetch it if it is allowed, and read its .hungryfox.yml
 func (r *Repo) Open() error {
+	if err := r.openOrUpdate(); err != nil {
+		return err
+	}
+	r.repoConfig, r.repoConfigErr = r.readRepoConfig()
+	return nil
+}
+
+// RepoConfig - settings from .hungryfox.yml in HEAD of repo, nil if there is no such file
+func (r *Repo) RepoConfig() (*config.RepoConfig, error) {
+	return r.repoConfig, r.repoConfigErr
+}
+
+func (r *Repo) readRepoConfig() (*config.RepoConfig, error) {
+	head, err := r.repository.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.repository.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(config.RepoConfigFile)
+	if err == object.ErrFileNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.Size > maxRepoConfigSize {
+		return nil, fmt.Errorf("%s is too big", config.RepoConfigFile)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseRepoConfig([]byte(content))
+}
+
+func (r *Repo) openOrUpdate() error {
 	if !r.AllowUpdate {
 		return r.open()
 	}
@@ -1,6 +1,11 @@
 package hungryfox
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
 
 type Diff struct {
 	CommitHash  string
@@ -16,6 +21,8 @@ type Diff struct {
 
 type RepoOptions struct {
 	AllowUpdate bool
+	// Inspect - index of inspect entry in config which found the repo
+	Inspect int
 }
 
 type RepoLocation struct {
@@ -26,7 +33,7 @@ type RepoLocation struct {
 }
 
 type RepoState struct {
-	Refs     []string
+	Refs []string
 }
 
 type ScanStatus struct {
@@ -36,11 +43,11 @@ type ScanStatus struct {
 }
 
 type Repo struct {
-	Options RepoOptions
+	Options  RepoOptions
 	Location RepoLocation
-	State RepoState
-	Scan ScanStatus
-	Repo IRepo
+	State    RepoState
+	Scan     ScanStatus
+	Repo     IRepo
 }
 
 type IMessageSender interface {
@@ -70,7 +77,15 @@ type IStateManager interface {
 	Save(Repo)
 }
 
+type ILeakStore interface {
+	Register(Leak) bool
+	Get(id string) (LeakRecord, bool)
+	List() []LeakRecord
+	SetState(id string, state LeakState, user, comment string) (LeakRecord, error)
+}
+
 type Leak struct {
+	ID           string    `json:"id,omitempty"`
 	PatternName  string    `json:"pattern_name"`
 	Regexp       string    `json:"pattern"`
 	FilePath     string    `json:"filepath"`
@@ -80,6 +95,207 @@ type Leak struct {
 	CommitHash   string    `json:"commit"`
 	TimeStamp    time.Time `json:"ts"`
 	Line         int       `json:"line"`
+	LineEnd      int       `json:"line_end,omitempty"`
 	CommitAuthor string    `json:"author"`
 	CommitEmail  string    `json:"email"`
+	Severity     Severity  `json:"severity,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Remediation  string    `json:"remediation,omitempty"`
+	// SecretStart, SecretEnd - position of secret in LeakString
+	SecretStart int    `json:"secret_start,omitempty"`
+	SecretEnd   int    `json:"secret_end,omitempty"`
+	SecretHash  string `json:"secret_hash,omitempty"`
+	// ContextBefore, ContextAfter - added lines around leak string
+	ContextBefore []string `json:"context_before,omitempty"`
+	ContextAfter  []string `json:"context_after,omitempty"`
+	// Encoding - layers of encoding like base64 or base64+url of secret which is found after decoding
+	Encoding string `json:"encoding,omitempty"`
+	// Owners - contacts of repo owners from its .hungryfox.yml
+	Owners []string `json:"owners,omitempty"`
+}
+
+// SecretHash - hash of secret without surrounding spaces and quotes which can be shown instead of it
+func SecretHash(secret string) string {
+	h := sha256.Sum256([]byte(normalizeSecret(secret)))
+	return hex.EncodeToString(h[:])
+}
+
+// Secret - part of leak string which is secret, whole string if it is unknown
+func (l Leak) Secret() string {
+	if l.SecretEnd <= l.SecretStart || l.SecretEnd > len(l.LeakString) {
+		return l.LeakString
+	}
+	return l.LeakString[l.SecretStart:l.SecretEnd]
+}
+
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+)
+
+// IsValid - check that severity is known
+func (s Severity) IsValid() bool {
+	return s.Rank() > 0
+}
+
+// Rank - bigger for more severe, 0 for unknown severity
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+type Redaction string
+
+const (
+	RedactionFull   Redaction = "full"
+	RedactionMasked Redaction = "masked"
+	RedactionHash   Redaction = "hash"
+)
+
+// IsValid - check that redaction is known
+func (r Redaction) IsValid() bool {
+	switch r {
+	case RedactionFull, RedactionMasked, RedactionHash:
+		return true
+	}
+	return false
+}
+
+// Redact - copy of leak for sending outside with masked secret or only hash of secret
+func (l Leak) Redact(r Redaction) Leak {
+	if r == RedactionFull {
+		return l
+	}
+	secret := l.Secret()
+	if l.SecretHash == "" {
+		l.SecretHash = SecretHash(secret)
+	}
+	if r == RedactionHash {
+		l.LeakString = "sha256:" + l.SecretHash
+		l.SecretStart, l.SecretEnd = 0, 0
+		l.ContextBefore, l.ContextAfter = nil, nil
+		return l
+	}
+	l.ContextBefore = maskLines(l.ContextBefore, secret)
+	l.ContextAfter = maskLines(l.ContextAfter, secret)
+	if l.SecretEnd <= l.SecretStart || l.SecretEnd > len(l.LeakString) {
+		l.LeakString = MaskSecret(secret)
+		return l
+	}
+	l.LeakString = l.LeakString[:l.SecretStart] + MaskSecret(secret) + l.LeakString[l.SecretEnd:]
+	return l
+}
+
+// maskLines - copy of lines with masked secret
+func maskLines(lines []string, secret string) []string {
+	if len(lines) == 0 || secret == "" {
+		return lines
+	}
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, strings.Replace(line, secret, MaskSecret(secret), -1))
+	}
+	return result
+}
+
+// MaskSecret - replace secret by stars except few chars at the edges of long secret
+func MaskSecret(secret string) string {
+	const prefix, suffix = 4, 3
+	if len(secret) < 12 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:prefix] + strings.Repeat("*", len(secret)-prefix-suffix) + secret[len(secret)-suffix:]
+}
+
+// Fingerprint - leak identifier by pattern and secret which doesn't depend on repo and commit,
+// the same secret in other files is the same leak unless withPath is set.
+// SecretHash is used when it is set, so decoded secret is the same leak as plain one
+func (l Leak) Fingerprint(withPath bool) string {
+	secretHash := l.SecretHash
+	if secretHash == "" {
+		secretHash = SecretHash(l.Secret())
+	}
+	h := sha256.New()
+	fields := []string{l.PatternName, secretHash}
+	if withPath {
+		fields = append(fields, l.FilePath)
+	}
+	for _, s := range fields {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func normalizeSecret(secret string) string {
+	return strings.Trim(strings.TrimSpace(secret), "\"'`")
+}
+
+// Location - place where leak was found
+func (l Leak) Location() LeakLocation {
+	return LeakLocation{
+		RepoURL:      l.RepoURL,
+		FilePath:     l.FilePath,
+		Line:         l.Line,
+		CommitHash:   l.CommitHash,
+		TimeStamp:    l.TimeStamp,
+		CommitAuthor: l.CommitAuthor,
+		CommitEmail:  l.CommitEmail,
+	}
+}
+
+type LeakLocation struct {
+	RepoURL      string    `json:"repo_url"`
+	FilePath     string    `json:"filepath"`
+	Line         int       `json:"line"`
+	CommitHash   string    `json:"commit"`
+	TimeStamp    time.Time `json:"ts"`
+	CommitAuthor string    `json:"author"`
+	CommitEmail  string    `json:"email"`
+}
+
+type LeakState string
+
+const (
+	LeakOpen          LeakState = "open"
+	LeakResolved      LeakState = "resolved"
+	LeakFalsePositive LeakState = "false_positive"
+	LeakAcceptedRisk  LeakState = "accepted_risk"
+)
+
+// IsValid - check that state is known
+func (s LeakState) IsValid() bool {
+	switch s {
+	case LeakOpen, LeakResolved, LeakFalsePositive, LeakAcceptedRisk:
+		return true
+	}
+	return false
+}
+
+type LeakRecord struct {
+	Leak      Leak      `json:"leak"`
+	State     LeakState `json:"state"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	ChangedAt time.Time `json:"changed_at,omitempty"`
+	ChangedBy string    `json:"changed_by,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	// Sightings - how many times the leak was found
+	Sightings int `json:"sightings"`
+	// Locations - other places where the same leak was found
+	Locations []LeakLocation `json:"locations,omitempty"`
 }
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "hungryfox"
+
+var (
+	// ReposDiscovered - repos found by last update of scan list
+	ReposDiscovered = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "repos_discovered",
+		Help:      "Number of repos found by inspect type.",
+	}, []string{"type"})
+
+	// Scans - started, succeeded and failed scans
+	Scans = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scans_total",
+		Help:      "Number of repo scans by status.",
+	}, []string{"status"})
+
+	// ScanDuration - duration of repo scan
+	ScanDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scan_duration_seconds",
+		Help:      "Duration of repo scan.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	})
+
+	// CommitsScanned - commits sent to searcher
+	CommitsScanned = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commits_scanned_total",
+		Help:      "Number of scanned commits.",
+	})
+
+	// DiffsProcessed - diffs processed by searcher
+	DiffsProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "diffs_processed_total",
+		Help:      "Number of diffs processed by searcher.",
+	})
+
+	// LeaksFound - leaks found by pattern
+	LeaksFound = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "leaks_found_total",
+		Help:      "Number of found leaks by pattern name.",
+	}, []string{"pattern"})
+
+	// LeaksFiltred - leaks skipped by filters
+	LeaksFiltred = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "leaks_filtred_total",
+		Help:      "Number of leaks skipped by filters by pattern name.",
+	}, []string{"pattern"})
+
+	// SenderSends - result of sending leak by each sender
+	SenderSends = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sender_sends_total",
+		Help:      "Number of leaks passed to senders by result.",
+	}, []string{"sender", "result"})
+
+	// EmailMessages - result of delivery of batched emails
+	EmailMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "email_messages_total",
+		Help:      "Number of sent emails by result.",
+	}, []string{"result"})
+)
+
+// RegisterQueue - export current length of channel
+func RegisterQueue(name string, length func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "queue_length",
+		Help:        "Number of items in queue.",
+		ConstLabels: prometheus.Labels{"queue": name},
+	}, func() float64 {
+		return float64(length())
+	})
+}
+
+// Result - label value for error
+func Result(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return "succeeded"
+}
@@ -3,19 +3,27 @@ package repolist
 import (
 	"time"
 
+	sync "github.com/sasha-s/go-deadlock"
+
 	"github.com/AlexAkulov/hungryfox"
 )
 
 type RepoList struct {
-	list []hungryfox.Repo
+	list  []hungryfox.Repo
+	queue []string
+	mutex sync.RWMutex
 	State hungryfox.IStateManager
 }
 
 func (l *RepoList) Clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	l.list = nil
 }
 
 func (l *RepoList) addRepo(r hungryfox.Repo) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	if l.list == nil {
 		l.list = make([]hungryfox.Repo, 0)
 	}
@@ -39,6 +47,8 @@ func (l *RepoList) UpdateRepo(r hungryfox.Repo) {
 }
 
 func (l *RepoList) GetRepoByIndex(i int) *hungryfox.Repo {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
 	if i > len(l.list)-1 || i < 0 {
 		return nil
 	}
@@ -47,6 +57,8 @@ func (l *RepoList) GetRepoByIndex(i int) *hungryfox.Repo {
 }
 
 func (l *RepoList) GetRepoForScan() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
 	rID := -1
 	lastScan := time.Now().UTC()
 	for i, r := range l.list {
@@ -61,6 +73,65 @@ func (l *RepoList) GetRepoForScan() int {
 	return rID
 }
 
+// Enqueue - add repo to queue for scan ahead of the others
+func (l *RepoList) Enqueue(url string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.indexOf(url) < 0 {
+		return false
+	}
+	for _, queued := range l.queue {
+		if queued == url {
+			return true
+		}
+	}
+	l.queue = append(l.queue, url)
+	return true
+}
+
+// PopQueued - get index of first repo from queue and remove it from queue
+func (l *RepoList) PopQueued() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for len(l.queue) > 0 {
+		url := l.queue[0]
+		l.queue = l.queue[1:]
+		if i := l.indexOf(url); i >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Queue - get urls of repos in queue
+func (l *RepoList) Queue() []string {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	result := make([]string, len(l.queue))
+	copy(result, l.queue)
+	return result
+}
+
+func (l *RepoList) indexOf(url string) int {
+	for i := range l.list {
+		if l.list[i].Location.URL == url {
+			return i
+		}
+	}
+	return -1
+}
+
 func (l *RepoList) GetTotalRepos() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
 	return len(l.list)
 }
+
+// GetAll - get copy of all repos in list
+func (l *RepoList) GetAll() []hungryfox.Repo {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	result := make([]hungryfox.Repo, len(l.list))
+	copy(result, l.list)
+	return result
+}
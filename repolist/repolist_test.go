@@ -81,3 +81,25 @@ func TestGetRepoForScan(t *testing.T) {
 		}
 	})
 }
+
+func TestQueue(t *testing.T) {
+	Convey("scan queue", t, func() {
+		now := time.Now().UTC()
+		testData := []hungryfox.Repo{
+			hungryfox.Repo{Location: hungryfox.RepoLocation{URL: "old"}, Scan: hungryfox.ScanStatus{StartTime: now.Add(-time.Hour), EndTime: now.Add(-time.Hour)}},
+			hungryfox.Repo{Location: hungryfox.RepoLocation{URL: "new"}, Scan: hungryfox.ScanStatus{StartTime: now, EndTime: now}},
+		}
+		rl := RepoList{State: FakeStateManager{data: testData}}
+		for _, r := range testData {
+			rl.AddRepo(r)
+		}
+		So(rl.PopQueued(), ShouldEqual, -1)
+		So(rl.Enqueue("unknown"), ShouldBeFalse)
+		So(rl.Enqueue("new"), ShouldBeTrue)
+		So(rl.Enqueue("new"), ShouldBeTrue)
+		So(rl.Queue(), ShouldResemble, []string{"new"})
+		So(rl.PopQueued(), ShouldEqual, 1)
+		So(rl.PopQueued(), ShouldEqual, -1)
+		So(rl.GetRepoForScan(), ShouldEqual, 0)
+	})
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/AlexAkulov/hungryfox"
 	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/events"
 	"github.com/AlexAkulov/hungryfox/helpers"
+	"github.com/AlexAkulov/hungryfox/metrics"
 	"github.com/AlexAkulov/hungryfox/senders/email"
 	"github.com/AlexAkulov/hungryfox/senders/file"
 	"github.com/AlexAkulov/hungryfox/senders/webhook"
@@ -16,11 +18,16 @@ import (
 
 type LeaksRouter struct {
 	LeakChannel <-chan *hungryfox.Leak
+	LeakStore   hungryfox.ILeakStore
+	Events      *events.Broker
 	Config      *config.Config
 	Log         zerolog.Logger
 
-	senders map[string]hungryfox.IMessageSender
-	tomb    tomb.Tomb
+	senders     map[string]hungryfox.IMessageSender
+	minSeverity map[string]hungryfox.Severity
+	// eventsRedaction - redaction of leaks published to events stream of API
+	eventsRedaction hungryfox.Redaction
+	tomb            tomb.Tomb
 }
 
 func (r *LeaksRouter) Start() error {
@@ -29,7 +36,22 @@ func (r *LeaksRouter) Start() error {
 		return fmt.Errorf("can't parse delay with: %v", err)
 	}
 	r.senders = map[string]hungryfox.IMessageSender{}
+	r.minSeverity = map[string]hungryfox.Severity{}
+	apiRedaction := ""
+	if r.Config.API != nil {
+		apiRedaction = r.Config.API.Redaction
+	}
+	if r.eventsRedaction, err = getRedaction(apiRedaction, hungryfox.RedactionMasked); err != nil {
+		return fmt.Errorf("bad api config: %v", err)
+	}
 	if r.Config.SMTP.Enable {
+		redaction, err := getRedaction(r.Config.SMTP.Redaction, hungryfox.RedactionMasked)
+		if err != nil {
+			return fmt.Errorf("bad smtp config: %v", err)
+		}
+		if r.minSeverity["email"], err = getSeverity(r.Config.SMTP.MinSeverity); err != nil {
+			return fmt.Errorf("bad smtp config: %v", err)
+		}
 		r.senders["email"] = &email.Sender{
 			AuditorEmail: r.Config.SMTP.Recipient,
 			Config: &email.Config{
@@ -41,20 +63,34 @@ func (r *LeaksRouter) Start() error {
 				Password:    r.Config.SMTP.Password,
 				Delay:       delay,
 			},
-			Log: r.Log,
+			Redaction: redaction,
+			Log:       r.Log,
 		}
 	}
 
-	if r.Config.WebHook.Enable {
+	if r.Config.WebHook != nil && r.Config.WebHook.Enable {
+		redaction, err := getRedaction(r.Config.WebHook.Redaction, hungryfox.RedactionMasked)
+		if err != nil {
+			return fmt.Errorf("bad webhook config: %v", err)
+		}
+		if r.minSeverity["webhook"], err = getSeverity(r.Config.WebHook.MinSeverity); err != nil {
+			return fmt.Errorf("bad webhook config: %v", err)
+		}
 		r.senders["webhook"] = &webhook.Sender{
-			Method:  r.Config.WebHook.Method,
-			URL:     r.Config.WebHook.URL,
-			Headers: r.Config.WebHook.Headers,
+			Method:    r.Config.WebHook.Method,
+			URL:       r.Config.WebHook.URL,
+			Headers:   r.Config.WebHook.Headers,
+			Redaction: redaction,
 		}
 	}
 
+	redaction, err := getRedaction(r.Config.Common.LeaksFileRedaction, hungryfox.RedactionFull)
+	if err != nil {
+		return fmt.Errorf("bad leaks_file_redaction: %v", err)
+	}
 	r.senders["file"] = &file.File{
 		LeaksFile: r.Config.Common.LeaksFile,
+		Redaction: redaction,
 	}
 
 	for senderName, sender := range r.senders {
@@ -70,15 +106,52 @@ func (r *LeaksRouter) Start() error {
 			case <-r.tomb.Dying(): // Stop
 				return nil
 			case leak := <-r.LeakChannel:
-				for _, sender := range r.senders {
-					sender.Send(*leak)
-				}
+				r.route(leak)
 			}
 		}
 	})
 	return nil
 }
 
+// route - send new or reopened leak to senders with suitable min_severity and publish it to events stream
+func (r *LeaksRouter) route(leak *hungryfox.Leak) {
+	leak.ID = leak.Fingerprint(r.Config.Common.DedupByPath)
+	if r.LeakStore != nil && !r.LeakStore.Register(*leak) {
+		r.Log.Debug().Str("id", leak.ID).Str("repo_url", leak.RepoURL).Str("commit", leak.CommitHash).Msg("leak already known")
+		return
+	}
+	for senderName, sender := range r.senders {
+		if leak.Severity.Rank() < r.minSeverity[senderName].Rank() {
+			continue
+		}
+		err := sender.Send(*leak)
+		if err != nil {
+			r.Log.Error().Str("error", err.Error()).Str("service", senderName).Msg("can't send leak")
+		}
+		metrics.SenderSends.WithLabelValues(senderName, metrics.Result(err)).Inc()
+	}
+	r.Events.Publish(events.Leak, leak.Redact(r.eventsRedaction))
+}
+
+func getRedaction(value string, defaultValue hungryfox.Redaction) (hungryfox.Redaction, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	redaction := hungryfox.Redaction(value)
+	if !redaction.IsValid() {
+		return "", fmt.Errorf("unknown redaction '%s'", value)
+	}
+	return redaction, nil
+}
+
+func getSeverity(value string) (hungryfox.Severity, error) {
+	severity := hungryfox.Severity(value)
+	if value != "" && !severity.IsValid() {
+		return "", fmt.Errorf("unknown severity '%s'", value)
+	}
+	return severity, nil
+}
+
 func (r *LeaksRouter) Stop() error {
 	r.tomb.Kill(nil)
 	r.tomb.Wait()
@@ -11,7 +11,6 @@ func (sm *ScanManager) DryRun() {
 		r := sm.repoList.GetRepoByIndex(i)
 		if r == nil {
 			panic("bad index")
-			return
 		}
 		if err := sm.getState(r); err != nil {
 			sm.Log.Error().Str("error", err.Error()).
@@ -35,6 +34,7 @@ func (sm *ScanManager) getState(r *hungryfox.Repo) error {
 		URL:              r.Location.URL,
 		CloneURL:         r.Location.CloneURL,
 		AllowUpdate:      r.Options.AllowUpdate,
+		ScanBinary:       sm.config.Common.ScanBinary,
 	}
 	if err := r.Repo.Open(); err != nil {
 		return err
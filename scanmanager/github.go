@@ -18,7 +18,7 @@ func getGitHubRepoPath(repoPath string) (string, error) {
 	return "", nil
 }
 
-func (sm *ScanManager) inspectGithub(inspect config.Inspect) error {
+func (sm *ScanManager) inspectGithub(index int, inspect config.Inspect) error {
 	githubClient := github.Client{
 		Token:   inspect.Token,
 		WorkDir: inspect.WorkDir,
@@ -60,7 +60,7 @@ func (sm *ScanManager) inspectGithub(inspect config.Inspect) error {
 	for repoLocation := range repoLocations {
 		sm.repoList.AddRepo(hungryfox.Repo{
 			Location: repoLocation,
-			Options:  hungryfox.RepoOptions{AllowUpdate: true},
+			Options:  hungryfox.RepoOptions{AllowUpdate: true, Inspect: index},
 		})
 	}
 
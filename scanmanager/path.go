@@ -46,7 +46,7 @@ func expandGlob(inspect config.Inspect) (map[string]struct{}, error) {
 	return scanPaths, nil
 }
 
-func (sm *ScanManager) inspectRepoPath(inspectObject config.Inspect) error {
+func (sm *ScanManager) inspectRepoPath(index int, inspectObject config.Inspect) error {
 	scanPathList, err := expandGlob(inspectObject)
 	if err != nil {
 		sm.Log.Error().Str("error", err.Error()).Msg("can't expand glob")
@@ -55,7 +55,7 @@ func (sm *ScanManager) inspectRepoPath(inspectObject config.Inspect) error {
 	for path := range scanPathList {
 		location := getRepoLocation(path, inspectObject)
 		sm.repoList.AddRepo(hungryfox.Repo{
-			Options:  hungryfox.RepoOptions{AllowUpdate: false},
+			Options:  hungryfox.RepoOptions{AllowUpdate: false, Inspect: index},
 			Location: location,
 		})
 	}
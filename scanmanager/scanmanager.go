@@ -1,28 +1,48 @@
 package scanmanager
 
 import (
+	"fmt"
 	"time"
 
+	sync "github.com/sasha-s/go-deadlock"
+
 	"github.com/AlexAkulov/hungryfox"
 	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/events"
 	"github.com/AlexAkulov/hungryfox/helpers"
 	"github.com/AlexAkulov/hungryfox/hercules"
+	"github.com/AlexAkulov/hungryfox/metrics"
 	"github.com/AlexAkulov/hungryfox/repolist"
 
 	"github.com/rs/zerolog"
 	"gopkg.in/tomb.v2"
 )
 
+// IRepoConfigSetter - applies .hungryfox.yml of repo to searching of leaks
+type IRepoConfigSetter interface {
+	SetRepoConfig(repoURL string, repoConfig *config.RepoConfig) error
+}
+
+// repoConfigReader - repo which has .hungryfox.yml
+type repoConfigReader interface {
+	RepoConfig() (*config.RepoConfig, error)
+}
+
 // ScanManager -
 type ScanManager struct {
 	DiffChannel  chan<- *hungryfox.Diff
 	Log          zerolog.Logger
 	StateManager hungryfox.IStateManager
+	Events       *events.Broker
+	RepoConfigs  IRepoConfigSetter
 
-	config      *config.Config
-	tomb        tomb.Tomb
-	currentRepo int
-	repoList    *repolist.RepoList
+	config   *config.Config
+	tomb     tomb.Tomb
+	repoList *repolist.RepoList
+	wakeUp   chan struct{}
+	// currentRepo - index of repo which is scanning now, it is set by scan goroutine and read by API
+	currentRepo  int
+	currentMutex sync.RWMutex
 }
 
 // SetConfig - update configuration
@@ -34,7 +54,59 @@ func (sm *ScanManager) SetConfig(config *config.Config) {
 
 // Status - get status for current repo
 func (sm *ScanManager) Status() *hungryfox.Repo {
-	return sm.repoList.GetRepoByIndex(sm.currentRepo)
+	sm.currentMutex.RLock()
+	current := sm.currentRepo
+	sm.currentMutex.RUnlock()
+	return sm.repoList.GetRepoByIndex(current)
+}
+
+func (sm *ScanManager) setCurrentRepo(index int) {
+	sm.currentMutex.Lock()
+	defer sm.currentMutex.Unlock()
+	sm.currentRepo = index
+}
+
+// Repos - get all repos for scan
+func (sm *ScanManager) Repos() []hungryfox.Repo {
+	return sm.repoList.GetAll()
+}
+
+// ScanRepoURL - scan repo ahead of the others as soon as possible
+func (sm *ScanManager) ScanRepoURL(url string) error {
+	if !sm.repoList.Enqueue(url) {
+		return fmt.Errorf("repo '%s' not found", url)
+	}
+	sm.Log.Info().Str("repo_url", url).Msg("repo added to scan queue")
+	sm.wake()
+	return nil
+}
+
+// ScanInspect - scan all repos of inspect entry ahead of the others as soon as possible
+func (sm *ScanManager) ScanInspect(index int) (int, error) {
+	if index < 0 || index >= len(sm.config.Inspect) {
+		return 0, fmt.Errorf("inspect %d not found", index)
+	}
+	count := 0
+	for _, r := range sm.repoList.GetAll() {
+		if r.Options.Inspect == index && sm.repoList.Enqueue(r.Location.URL) {
+			count++
+		}
+	}
+	sm.Log.Info().Int("inspect", index).Int("repos", count).Msg("repos added to scan queue")
+	sm.wake()
+	return count, nil
+}
+
+// Queue - urls of repos which will be scanned ahead of the others
+func (sm *ScanManager) Queue() []string {
+	return sm.repoList.Queue()
+}
+
+func (sm *ScanManager) wake() {
+	select {
+	case sm.wakeUp <- struct{}{}:
+	default:
+	}
 }
 
 func (sm *ScanManager) updateScanList() {
@@ -43,15 +115,22 @@ func (sm *ScanManager) updateScanList() {
 		sm.repoList = &repolist.RepoList{State: sm.StateManager}
 	}
 	sm.repoList.Clear()
-	for _, inspectObject := range sm.config.Inspect {
+	discovered := map[string]int{}
+	for i, inspectObject := range sm.config.Inspect {
+		total := sm.repoList.GetTotalRepos()
 		switch inspectObject.Type {
 		case "path":
-			sm.inspectRepoPath(inspectObject)
+			sm.inspectRepoPath(i, inspectObject)
 		case "github":
-			sm.inspectGithub(inspectObject)
+			sm.inspectGithub(i, inspectObject)
 		default:
 			sm.Log.Error().Str("type", inspectObject.Type).Msg("unsupported type")
+			continue
 		}
+		discovered[inspectObject.Type] += sm.repoList.GetTotalRepos() - total
+	}
+	for inspectType, count := range discovered {
+		metrics.ReposDiscovered.WithLabelValues(inspectType).Set(float64(count))
 	}
 	sm.Log.Debug().Str("status", "complete").Msg("update scan list")
 }
@@ -59,7 +138,8 @@ func (sm *ScanManager) updateScanList() {
 // Start - start ScanManager instance
 func (sm *ScanManager) Start(config *config.Config) error {
 	sm.config = config
-	sm.currentRepo = -1
+	sm.setCurrentRepo(-1)
+	sm.wakeUp = make(chan struct{}, 1)
 	sm.updateScanList()
 
 	sm.tomb.Go(func() error {
@@ -73,6 +153,9 @@ func (sm *ScanManager) Start(config *config.Config) error {
 				sm.updateScanList()
 			case <-scanTimer.C:
 				scanTimer = sm.scanNext()
+			case <-sm.wakeUp:
+				scanTimer.Stop()
+				scanTimer = time.NewTimer(0)
 			}
 		}
 	})
@@ -88,20 +171,23 @@ func (sm *ScanManager) Stop() error {
 }
 
 func (sm *ScanManager) scanNext() *time.Timer {
-	rID := sm.repoList.GetRepoForScan()
+	queued := true
+	rID := sm.repoList.PopQueued()
+	if rID < 0 {
+		queued = false
+		rID = sm.repoList.GetRepoForScan()
+	}
 	if rID < 0 {
 		waitTime := time.Duration(time.Minute)
 		sm.Log.Debug().Str("wait", helpers.PrettyDuration(waitTime)).Msg("no repo for scan")
 		return time.NewTimer(waitTime)
 	}
-	sm.currentRepo = rID
-	defer func() {
-		sm.currentRepo = -1
-	}()
+	sm.setCurrentRepo(rID)
+	defer sm.setCurrentRepo(-1)
 	r := sm.repoList.GetRepoByIndex(rID)
 	elapsedTime := time.Since(r.Scan.EndTime)
-	if elapsedTime > sm.config.Common.ScanInterval {
-		sm.Log.Info().Str("data_path", r.Location.DataPath).Str("repo_path", r.Location.RepoPath).Msg("start scan")
+	if queued || elapsedTime > sm.config.Common.ScanInterval {
+		sm.Log.Info().Str("data_path", r.Location.DataPath).Str("repo_path", r.Location.RepoPath).Bool("queued", queued).Msg("start scan")
 		sm.ScanRepo(rID)
 		return time.NewTimer(0)
 	}
@@ -125,13 +211,21 @@ func (sm *ScanManager) ScanRepo(index int) {
 		URL:              r.Location.URL,
 		CloneURL:         r.Location.CloneURL,
 		AllowUpdate:      r.Options.AllowUpdate,
+		ScanBinary:       sm.config.Common.ScanBinary,
 	}
 	r.Repo.SetRefs(r.State.Refs)
 	startScan := time.Now().UTC()
 	r.Scan.StartTime = startScan
 	sm.repoList.UpdateRepo(*r)
+	metrics.Scans.WithLabelValues("started").Inc()
+	sm.Events.Publish(events.ScanStarted, events.ScanData{
+		RepoURL:   r.Location.URL,
+		StartTime: startScan,
+	})
 
-	err := openScanClose(*r)
+	err := sm.openScanClose(*r)
+	metrics.Scans.WithLabelValues(metrics.Result(err)).Inc()
+	metrics.ScanDuration.Observe(time.Since(startScan).Seconds())
 	r.State.Refs = r.Repo.GetRefs()
 	newR := hungryfox.Repo{
 		Location: r.Location,
@@ -144,6 +238,16 @@ func (sm *ScanManager) ScanRepo(index int) {
 		},
 	}
 	sm.repoList.UpdateRepo(newR)
+	scanData := events.ScanData{
+		RepoURL:   newR.Location.URL,
+		StartTime: newR.Scan.StartTime,
+		EndTime:   newR.Scan.EndTime,
+		Success:   newR.Scan.Success,
+	}
+	if err != nil {
+		scanData.Error = err.Error()
+	}
+	sm.Events.Publish(events.ScanFinished, scanData)
 
 	if err != nil {
 		sm.Log.Error().Str("data_path", newR.Location.DataPath).Str("repo_path", newR.Location.RepoPath).Str("error", err.Error()).Msg("scan failed")
@@ -153,10 +257,30 @@ func (sm *ScanManager) ScanRepo(index int) {
 	return
 }
 
-func openScanClose(r hungryfox.Repo) error {
+func (sm *ScanManager) openScanClose(r hungryfox.Repo) error {
 	if err := r.Repo.Open(); err != nil {
 		return err
 	}
 	defer r.Repo.Close()
+	sm.applyRepoConfig(r)
 	return r.Repo.Scan()
 }
+
+// applyRepoConfig - pass .hungryfox.yml of repo to searcher, broken file is ignored and doesn't stop scan
+func (sm *ScanManager) applyRepoConfig(r hungryfox.Repo) {
+	reader, ok := r.Repo.(repoConfigReader)
+	if sm.RepoConfigs == nil || !ok {
+		return
+	}
+	var repoConfig *config.RepoConfig
+	if !sm.config.Common.DisableRepoConfig {
+		var err error
+		if repoConfig, err = reader.RepoConfig(); err != nil {
+			sm.Log.Error().Str("repo_url", r.Location.URL).Str("error", err.Error()).Msg("can't read repo config")
+		}
+	}
+	if err := sm.RepoConfigs.SetRepoConfig(r.Location.URL, repoConfig); err != nil {
+		sm.Log.Error().Str("repo_url", r.Location.URL).Str("error", err.Error()).Msg("can't apply repo config")
+		sm.RepoConfigs.SetRepoConfig(r.Location.URL, nil)
+	}
+}
@@ -1 +1,42 @@
 package scanmanager
+
+import (
+	"testing"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/repolist"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeStateManager struct{}
+
+func (fakeStateManager) Load(string) (hungryfox.RepoState, hungryfox.ScanStatus) {
+	return hungryfox.RepoState{}, hungryfox.ScanStatus{}
+}
+func (fakeStateManager) Save(hungryfox.Repo) {}
+
+func TestStatus(t *testing.T) {
+	Convey("Test status of current scan", t, func() {
+		sm := &ScanManager{repoList: &repolist.RepoList{State: fakeStateManager{}}}
+		sm.repoList.AddRepo(hungryfox.Repo{Location: hungryfox.RepoLocation{URL: "https://example.com/repo"}})
+		sm.setCurrentRepo(-1)
+		So(sm.Status(), ShouldBeNil)
+
+		Convey("is read while scan goroutine changes it", func() {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < 1000; i++ {
+					sm.setCurrentRepo(i % 2)
+				}
+				sm.setCurrentRepo(0)
+			}()
+			for i := 0; i < 1000; i++ {
+				sm.Status()
+			}
+			<-done
+			So(sm.Status().Location.URL, ShouldEqual, "https://example.com/repo")
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package searcher
+
+// ahoCorasick - automaton for search many keywords in one pass, keywords and text are compared in lower case
+type ahoCorasick struct {
+	next    []int32 // 256 transitions for each state
+	outputs [][]int // ids of keywords which end in state
+}
+
+func newAhoCorasick(keywords map[string][]int) *ahoCorasick {
+	a := &ahoCorasick{}
+	a.addState()
+	for keyword, ids := range keywords {
+		state := 0
+		for i := 0; i < len(keyword); i++ {
+			c := int(toLowerASCII(keyword[i]))
+			if a.next[state*256+c] < 0 {
+				a.next[state*256+c] = int32(a.addState())
+			}
+			state = int(a.next[state*256+c])
+		}
+		a.outputs[state] = append(a.outputs[state], ids...)
+	}
+
+	// breadth-first build of failure links, missing transitions are replaced by transitions of failure state
+	fail := make([]int, len(a.outputs))
+	queue := []int{}
+	for c := 0; c < 256; c++ {
+		if a.next[c] < 0 {
+			a.next[c] = 0
+			continue
+		}
+		queue = append(queue, int(a.next[c]))
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		a.outputs[state] = append(a.outputs[state], a.outputs[fail[state]]...)
+		for c := 0; c < 256; c++ {
+			child := a.next[state*256+c]
+			if child < 0 {
+				a.next[state*256+c] = a.next[fail[state]*256+c]
+				continue
+			}
+			fail[child] = int(a.next[fail[state]*256+c])
+			queue = append(queue, int(child))
+		}
+	}
+	return a
+}
+
+func (a *ahoCorasick) addState() int {
+	for c := 0; c < 256; c++ {
+		a.next = append(a.next, -1)
+	}
+	a.outputs = append(a.outputs, nil)
+	return len(a.outputs) - 1
+}
+
+// Match - mark ids of all keywords found in text
+func (a *ahoCorasick) Match(text string, found []bool) {
+	state := 0
+	for i := 0; i < len(text); i++ {
+		state = int(a.next[state*256+int(toLowerASCII(text[i]))])
+		for _, id := range a.outputs[state] {
+			found[id] = true
+		}
+	}
+}
+
+func lowerASCII(s string) string {
+	result := []byte(s)
+	for i := range result {
+		result[i] = toLowerASCII(result[i])
+	}
+	return string(result)
+}
+
+func toLowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
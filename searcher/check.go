@@ -0,0 +1,127 @@
+package searcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/config"
+
+	"github.com/rs/zerolog"
+)
+
+// PatternProblem - pattern which can't be compiled or doesn't pass its examples
+type PatternProblem struct {
+	File    string
+	Pattern string
+	Problem string
+}
+
+func (p PatternProblem) String() string {
+	if p.Pattern == "" {
+		return fmt.Sprintf("%s: %s", p.File, p.Problem)
+	}
+	return fmt.Sprintf("%s: pattern '%s': %s", p.File, p.Pattern, p.Problem)
+}
+
+// CheckReport - result of checking patterns
+type CheckReport struct {
+	Patterns int
+	// WithoutExamples - patterns and filters which have no examples, they are not problems
+	WithoutExamples []PatternProblem
+	Problems        []PatternProblem
+}
+
+// CheckConfig - check patterns and filters from config and from patterns_path and filters_path,
+// and hashes of known_secrets_path
+func CheckConfig(conf *config.Config) CheckReport {
+	report := CheckReport{}
+	report.checkPatterns("config patterns", conf.Patterns, false)
+	report.checkPatterns("config filters", conf.Filters, true)
+	if conf.Common != nil && conf.Common.PatternsPath != "" {
+		report.CheckPath(conf.Common.PatternsPath, false)
+	}
+	if conf.Common != nil && conf.Common.FiltresPath != "" {
+		report.CheckPath(conf.Common.FiltresPath, true)
+	}
+	if conf.Common != nil && conf.Common.KnownSecretsPath != "" {
+		if _, err := loadKnownSecrets(conf.Common.KnownSecretsPath); err != nil {
+			report.Problems = append(report.Problems, PatternProblem{File: conf.Common.KnownSecretsPath, Problem: err.Error()})
+		}
+	}
+	return report
+}
+
+// CheckPath - check pattern files which are found by glob the same way as they are loaded
+func (r *CheckReport) CheckPath(path string, filters bool) {
+	files, err := filepath.Glob(path)
+	if err != nil {
+		r.Problems = append(r.Problems, PatternProblem{File: path, Problem: err.Error()})
+		return
+	}
+	if len(files) == 0 {
+		r.Problems = append(r.Problems, PatternProblem{File: path, Problem: "no files found"})
+	}
+	for _, file := range files {
+		rawPatterns, err := readPatternsFile(file)
+		if err != nil {
+			r.Problems = append(r.Problems, PatternProblem{File: file, Problem: err.Error()})
+			continue
+		}
+		r.checkPatterns(file, rawPatterns, filters)
+	}
+}
+
+func (r *CheckReport) checkPatterns(file string, rawPatterns []config.Pattern, filters bool) {
+	for i, rawPattern := range rawPatterns {
+		r.Patterns++
+		name := rawPattern.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		compiled, err := compileRules([]config.Pattern{rawPattern}, filters)
+		if err != nil {
+			r.Problems = append(r.Problems, PatternProblem{File: file, Pattern: name, Problem: err.Error()})
+			continue
+		}
+		if len(rawPattern.Examples) == 0 {
+			r.WithoutExamples = append(r.WithoutExamples, PatternProblem{File: file, Pattern: name, Problem: "no examples"})
+		}
+		p := &compiled[0]
+		for _, example := range rawPattern.Examples {
+			if !p.matchExample(example, filters) {
+				r.Problems = append(r.Problems, PatternProblem{File: file, Pattern: name, Problem: fmt.Sprintf("example '%s' doesn't match", example)})
+			}
+		}
+		for _, counterexample := range rawPattern.Counterexamples {
+			if p.matchExample(counterexample, filters) {
+				r.Problems = append(r.Problems, PatternProblem{File: file, Pattern: name, Problem: fmt.Sprintf("counterexample '%s' matches", counterexample)})
+			}
+		}
+	}
+}
+
+// matchExample - check example the same way as diff is checked by pattern or leak string by filter,
+// file and exclude_file of pattern are not checked, example of key_name pattern is YAML or INI file
+func (p *patternType) matchExample(example string, filter bool) bool {
+	if filter {
+		return p.ContentRe.MatchString(example)
+	}
+	pattern := *p
+	pattern.FileRe, pattern.ExcludeFileRe = matchAllRegex, nil
+	s := Searcher{Log: zerolog.Nop(), patterns: []patternType{pattern}}
+	s.matcher = newMatcher(s.patterns)
+	return len(s.GetLeaks(hungryfox.Diff{FilePath: exampleFile(p, example), Content: example})) > 0
+}
+
+// exampleFile - name of file with example which defines how it is parsed
+func exampleFile(p *patternType, example string) string {
+	if p.KeyNameRe == nil {
+		return "example"
+	}
+	if _, ok := parseYAML(strings.Split(example, "\n")); ok {
+		return "example.yml"
+	}
+	return "example.ini"
+}
@@ -0,0 +1,59 @@
+package searcher
+
+import (
+	"testing"
+
+	"github.com/AlexAkulov/hungryfox/config"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckPatterns(t *testing.T) {
+	Convey("Test CheckConfig", t, func() {
+		Convey("bundled patterns and filters pass their examples", func() {
+			report := CheckConfig(&config.Config{Common: &config.Common{
+				PatternsPath: "../pkg/patterns/*/*.yml",
+				FiltresPath:  "../pkg/filters/*.yml",
+			}})
+			So(report.Problems, ShouldBeEmpty)
+			So(report.Patterns, ShouldBeGreaterThan, len(report.WithoutExamples))
+		})
+
+		Convey("failed cases and broken patterns are reported", func() {
+			report := CheckConfig(&config.Config{
+				Patterns: []config.Pattern{
+					{Name: "broken", Content: "(abc"},
+					{Name: "digits", Content: `foo\d+`, Examples: []string{"foo", "line\nfoo1"}, Counterexamples: []string{"foo2"}},
+					{Name: "no examples", Content: "bar"},
+				},
+				Filters: []config.Pattern{
+					{Name: "filter", File: `\.js$`, Content: "test", Examples: []string{"test data"}, Counterexamples: []string{"prod"}},
+				},
+			})
+			So(report.Patterns, ShouldEqual, 4)
+			So(report.WithoutExamples, ShouldHaveLength, 1)
+			So(report.WithoutExamples[0].String(), ShouldEqual, "config patterns: pattern 'no examples': no examples")
+			So(report.Problems, ShouldHaveLength, 3)
+			So(report.Problems[0].Pattern, ShouldEqual, "broken")
+			So(report.Problems[1].String(), ShouldEqual, "config patterns: pattern 'digits': example 'foo' doesn't match")
+			So(report.Problems[2].String(), ShouldEqual, "config patterns: pattern 'digits': counterexample 'foo2' matches")
+		})
+
+		Convey("examples are checked with keywords prefilter", func() {
+			report := CheckConfig(&config.Config{
+				Patterns: []config.Pattern{
+					{Name: "keywords", Content: `[a-z]+_[0-9]+`, Keywords: []string{"token"}, Examples: []string{"token_123", "value_123"}},
+					{Name: "password", KeyName: `(?i)password$`, Examples: []string{"db:\n  password: s3cr3t", "db.password=s3cr3t"}, Counterexamples: []string{"password: changeme"}},
+				},
+			})
+			So(report.Problems, ShouldHaveLength, 1)
+			So(report.Problems[0].String(), ShouldEqual, "config patterns: pattern 'keywords': example 'value_123' doesn't match")
+		})
+
+		Convey("missing files are reported", func() {
+			report := CheckConfig(&config.Config{Common: &config.Common{PatternsPath: "../pkg/nothing/*.yml"}})
+			So(report.Problems, ShouldHaveLength, 1)
+			So(report.Problems[0].Problem, ShouldEqual, "no files found")
+		})
+	})
+}
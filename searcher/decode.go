@@ -0,0 +1,157 @@
+package searcher
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDecodeDepth - limit of nested encodings like base64 of hex
+const maxDecodeDepth = 2
+
+// decodedBlob - encoded substring of line and its decoded text,
+// nested encoded substrings are replaced by their decoded text
+type decodedBlob struct {
+	// Encoding - base64, hex or url, nested layers are joined by '+' from outer to inner
+	Encoding string
+	// Start, End - position of encoded substring in line
+	Start int
+	End   int
+	Text  string
+}
+
+// decodeBlobs - find base64, hex and url encoded substrings not shorter than minLength
+// which are decoded to printable text
+func decodeBlobs(line string, minLength int) []decodedBlob {
+	return decodeLayers(line, minLength, 1)
+}
+
+// decodedLine - line with encoded substring replaced by its decoded text
+func (b decodedBlob) decodedLine(line string) string {
+	return line[:b.Start] + b.Text + line[b.End:]
+}
+
+func decodeLayers(line string, minLength, depth int) []decodedBlob {
+	result := []decodedBlob{}
+	for _, blob := range findEncoded(line, minLength) {
+		result = append(result, blob)
+		if depth >= maxDecodeDepth {
+			continue
+		}
+		for _, inner := range decodeLayers(blob.Text, minLength, depth+1) {
+			result = append(result, decodedBlob{
+				Encoding: blob.Encoding + "+" + inner.Encoding,
+				Start:    blob.Start,
+				End:      blob.End,
+				Text:     blob.Text[:inner.Start] + inner.Text + blob.Text[inner.End:],
+			})
+		}
+	}
+	return result
+}
+
+func findEncoded(line string, minLength int) []decodedBlob {
+	result := []decodedBlob{}
+	for start := 0; start < len(line); {
+		if !isEncodedChar(line[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(line) && isEncodedChar(line[end]) {
+			end++
+		}
+		// padding is only at the end, key=value is not a single token
+		for pad := 0; pad < 2 && end < len(line) && line[end] == '='; pad++ {
+			end++
+		}
+		if end-start >= minLength {
+			if blob, ok := decodeToken(line[start:end]); ok {
+				blob.Start, blob.End = start, end
+				result = append(result, blob)
+			}
+		}
+		start = end
+	}
+	if strings.Contains(line, "%") {
+		result = append(result, findURLEncoded(line, minLength)...)
+	}
+	return result
+}
+
+func decodeToken(token string) (decodedBlob, bool) {
+	if len(token)%2 == 0 && isHex(token) {
+		if decoded, err := hex.DecodeString(token); err == nil && isPrintable(decoded) {
+			return decodedBlob{Encoding: "hex", Text: string(decoded)}, true
+		}
+		return decodedBlob{}, false
+	}
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(token, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(token, "=") {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	decoded, err := encoding.DecodeString(token)
+	if err != nil || !isPrintable(decoded) {
+		return decodedBlob{}, false
+	}
+	return decodedBlob{Encoding: "base64", Text: string(decoded)}, true
+}
+
+func findURLEncoded(line string, minLength int) []decodedBlob {
+	result := []decodedBlob{}
+	for start := 0; start < len(line); {
+		if isURLDelimiter(line[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(line) && !isURLDelimiter(line[end]) {
+			end++
+		}
+		token := line[start:end]
+		if end-start >= minLength && strings.Contains(token, "%") {
+			if decoded, err := url.QueryUnescape(token); err == nil && decoded != token && isPrintable([]byte(decoded)) {
+				result = append(result, decodedBlob{Encoding: "url", Start: start, End: end, Text: decoded})
+			}
+		}
+		start = end
+	}
+	return result
+}
+
+// isEncodedChar - char of base64 token except padding
+func isEncodedChar(c byte) bool {
+	return c != '=' && isBase64Char(c)
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isHexChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isURLDelimiter(c byte) bool {
+	return c == ' ' || c == '\t' || c == '"' || c == '\'' || c == '<' || c == '>' || c == '`'
+}
+
+// isPrintable - decoded data looks like text, not like random bytes
+func isPrintable(data []byte) bool {
+	if len(data) == 0 || !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package searcher
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/AlexAkulov/hungryfox/config"
+)
+
+const (
+	charsetBase64 = "base64"
+	charsetHex    = "hex"
+)
+
+type entropyType struct {
+	Charset    string
+	MinLength  int
+	MinEntropy float64
+	inCharset  func(byte) bool
+}
+
+func isBase64Char(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '+' || c == '/' || c == '=' || c == '_' || c == '-'
+}
+
+func isHexChar(c byte) bool {
+	return c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' || c >= '0' && c <= '9'
+}
+
+func compileEntropy(e *config.Entropy) (*entropyType, error) {
+	if e == nil {
+		return nil, nil
+	}
+	result := &entropyType{
+		Charset:    e.Charset,
+		MinLength:  e.MinLength,
+		MinEntropy: e.MinEntropy,
+	}
+	switch e.Charset {
+	case charsetBase64:
+		result.inCharset = isBase64Char
+		if result.MinLength == 0 {
+			result.MinLength = 20
+		}
+		if result.MinEntropy == 0 {
+			result.MinEntropy = 4.5
+		}
+	case charsetHex:
+		result.inCharset = isHexChar
+		if result.MinLength == 0 {
+			result.MinLength = 20
+		}
+		if result.MinEntropy == 0 {
+			result.MinEntropy = 3
+		}
+	default:
+		return nil, fmt.Errorf("unknown entropy charset '%s'", e.Charset)
+	}
+	return result, nil
+}
+
+// String - human readable rule for leak report
+func (e *entropyType) String() string {
+	return fmt.Sprintf("entropy(%s, length>=%d, entropy>=%.2f)", e.Charset, e.MinLength, e.MinEntropy)
+}
+
+// config - rule as it is in configuration
+func (e *entropyType) config() *config.Entropy {
+	if e == nil {
+		return nil
+	}
+	return &config.Entropy{
+		Charset:    e.Charset,
+		MinLength:  e.MinLength,
+		MinEntropy: e.MinEntropy,
+	}
+}
+
+// FindToken - get position of first random-looking token in string
+func (e *entropyType) FindToken(s string) (int, int, bool) {
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && e.inCharset(s[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= e.MinLength && shannonEntropy(s[start:i]) >= e.MinEntropy {
+			return start, i, true
+		}
+		start = -1
+	}
+	return 0, 0, false
+}
+
+// shannonEntropy - bits per character
+func shannonEntropy(s string) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	freq := [256]int{}
+	for i := 0; i < len(s); i++ {
+		freq[s[i]]++
+	}
+	result := 0.0
+	length := float64(len(s))
+	for _, count := range freq {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / length
+		result -= p * math.Log2(p)
+	}
+	return result
+}
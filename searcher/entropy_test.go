@@ -0,0 +1,68 @@
+package searcher
+
+import (
+	"testing"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/config"
+
+	"github.com/rs/zerolog"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	Convey("Test shannonEntropy", t, func() {
+		So(shannonEntropy(""), ShouldEqual, 0)
+		So(shannonEntropy("aaaa"), ShouldEqual, 0)
+		So(shannonEntropy("abab"), ShouldEqual, 1)
+		So(shannonEntropy("0123456789abcdef"), ShouldEqual, 4)
+	})
+}
+
+func TestFindToken(t *testing.T) {
+	Convey("Test FindToken", t, func() {
+		base64, err := compileEntropy(&config.Entropy{Charset: "base64"})
+		So(err, ShouldBeNil)
+		line := `aws_secret = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY";`
+		start, end, ok := base64.FindToken(line)
+		So(ok, ShouldBeTrue)
+		So(line[start:end], ShouldEqual, "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+		_, _, ok = base64.FindToken(`password = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"`)
+		So(ok, ShouldBeFalse)
+		_, _, ok = base64.FindToken(`import "github.com/AlexAkulov/hungryfox/config"`)
+		So(ok, ShouldBeFalse)
+
+		hex, err := compileEntropy(&config.Entropy{Charset: "hex", MinLength: 32})
+		So(err, ShouldBeNil)
+		start, end, ok = hex.FindToken(`token: 9f86d081884c7d659a2feaa0c55ad015`)
+		So(ok, ShouldBeTrue)
+		So([]int{start, end}, ShouldResemble, []int{7, 39})
+		_, _, ok = hex.FindToken(`token: 9f86d081884c7d65`)
+		So(ok, ShouldBeFalse)
+
+		_, err = compileEntropy(&config.Entropy{Charset: "base32"})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestEntropyPattern(t *testing.T) {
+	Convey("Test entropy pattern with key", t, func() {
+		patterns, err := compilePatterns([]config.Pattern{{
+			Name:    "random secret",
+			Key:     `(?i)(secret|token|passwd)\w*\s*[:=]`,
+			Entropy: &config.Entropy{Charset: "base64"},
+		}})
+		So(err, ShouldBeNil)
+		obj := Searcher{Log: zerolog.Nop(), patterns: patterns}
+		leaks := obj.GetLeaks(hungryfox.Diff{
+			FilePath: "settings.py",
+			Content: "API_TOKEN = 'xK9mQ2vL8pR4tW7yB3nF6hJ1cD5gZ0aS'\n" +
+				"URL = 'xK9mQ2vL8pR4tW7yB3nF6hJ1cD5gZ0aS'\n" +
+				"SECRET_KEY = 'changeme'\n",
+		})
+		So(len(leaks), ShouldEqual, 1)
+		So(leaks[0].LeakString, ShouldEqual, "API_TOKEN = 'xK9mQ2vL8pR4tW7yB3nF6hJ1cD5gZ0aS'")
+		So(leaks[0].Regexp, ShouldStartWith, "key(")
+		So(leaks[0].Secret(), ShouldEqual, "xK9mQ2vL8pR4tW7yB3nF6hJ1cD5gZ0aS")
+	})
+}
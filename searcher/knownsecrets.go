@@ -0,0 +1,143 @@
+package searcher
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlexAkulov/hungryfox"
+)
+
+const (
+	// knownSecretPatternName - name of pattern of leaks found by known_secrets_path
+	knownSecretPatternName = "Known secret"
+	// knownSecretRule - rule of leaks found by known_secrets_path
+	knownSecretRule = "sha256 in known_secrets_path"
+	// minKnownSecretLength - shorter tokens are not hashed
+	minKnownSecretLength = 6
+	// knownSecretDelimiters - chars inside of token which separate parts of it like in user:password@host
+	knownSecretDelimiters = "=:@/?&"
+)
+
+// knownSecrets - labels of secrets by their SHA-256 hashes
+type knownSecrets map[string]string
+
+// knownSecretMatch - token of line which hash is known
+type knownSecretMatch struct {
+	Label string
+	Start int
+	End   int
+}
+
+// loadKnownSecrets - read files found by glob, each line is hex SHA-256 of secret and its label
+// separated by space, like output of sha256sum, empty lines and lines started with # are skipped
+func loadKnownSecrets(path string) (knownSecrets, error) {
+	files, err := filepath.Glob(path)
+	if err != nil {
+		return nil, err
+	}
+	result := knownSecrets{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("can't read file '%s' with: %v", file, err)
+		}
+		if err := result.parse(data); err != nil {
+			return nil, fmt.Errorf("can't parse file '%s' with: %v", file, err)
+		}
+	}
+	return result, nil
+}
+
+func (k knownSecrets) parse(data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, label := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			hash, label = line[:i], strings.TrimSpace(line[i:])
+		}
+		if len(hash) != 64 || !isHex(hash) {
+			return fmt.Errorf("line %d: '%s' is not SHA-256 hash", n, hash)
+		}
+		k[strings.ToLower(hash)] = label
+	}
+	return scanner.Err()
+}
+
+// find - tokens of line which hashes are known, tokens are separated by spaces, quotes and brackets,
+// parts of token between '=', ':', '@', '/', '?' and '&' are checked too
+func (k knownSecrets) find(line string) []knownSecretMatch {
+	result := []knownSecretMatch{}
+	checked := map[[2]int]bool{}
+	check := func(start, end int) {
+		if end-start < minKnownSecretLength || checked[[2]int{start, end}] {
+			return
+		}
+		checked[[2]int{start, end}] = true
+		if label, ok := k[hungryfox.SecretHash(line[start:end])]; ok {
+			result = append(result, knownSecretMatch{Label: label, Start: start, End: end})
+		}
+	}
+	for start := 0; start < len(line); {
+		if isTokenDelimiter(line[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(line) && !isTokenDelimiter(line[end]) {
+			end++
+		}
+		check(start, end)
+		// user:password@host has password between delimiters, key=c2VjcmV0== has padding after it
+		bounds := []int{start - 1}
+		for i := start; i < end; i++ {
+			if strings.IndexByte(knownSecretDelimiters, line[i]) >= 0 {
+				bounds = append(bounds, i)
+			}
+		}
+		bounds = append(bounds, end)
+		for i := 1; i < len(bounds)-1; i++ {
+			check(start, bounds[i])
+			check(bounds[i]+1, end)
+			check(bounds[i-1]+1, bounds[i])
+		}
+		check(bounds[len(bounds)-2]+1, end)
+		start = end
+	}
+	return result
+}
+
+// pattern - pattern of leak found by known secret
+func (m knownSecretMatch) pattern() *patternType {
+	return &patternType{
+		Name:        knownSecretPatternName,
+		ContentRe:   matchAllRegex,
+		Severity:    hungryfox.SeverityCritical,
+		Description: m.Label,
+		Strong:      true,
+	}
+}
+
+// newKnownSecretLeak - leak found in line n by known secret, token is secret of leak
+func newKnownSecretLeak(diff hungryfox.Diff, lines []string, n int, match knownSecretMatch, contextLines int) hungryfox.Leak {
+	leak := newLeak(diff, match.pattern(), lines, n, match.Start, match.End, contextLines)
+	leak.Regexp = knownSecretRule
+	return leak
+}
+
+// isKnownSecretLeak - leak is found by known_secrets_path, not by pattern
+func isKnownSecretLeak(leak hungryfox.Leak) bool {
+	return leak.PatternName == knownSecretPatternName && leak.Regexp == knownSecretRule
+}
+
+func isTokenDelimiter(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '"' || c == '\'' || c == '`' || c == ',' || c == ';' ||
+		c == '(' || c == ')' || c == '[' || c == ']' || c == '{' || c == '}' || c == '<' || c == '>'
+}
@@ -0,0 +1,167 @@
+package searcher
+
+import (
+	"regexp"
+	"regexp/syntax"
+)
+
+// minKeywordLength - shorter keywords pass too many lines to be useful
+const minKeywordLength = 2
+
+// matcher - finds patterns which can match line so only them are checked by regexps
+type matcher struct {
+	patterns  []patternType
+	keywords  [][]string // nil - pattern has no keywords and is checked on every line
+	prefilter *ahoCorasick
+}
+
+func newMatcher(patterns []patternType) *matcher {
+	m := &matcher{
+		patterns: patterns,
+		keywords: make([][]string, len(patterns)),
+	}
+	index := map[string][]int{}
+	for i := range patterns {
+		m.keywords[i] = patternKeywords(patterns[i])
+		for _, keyword := range m.keywords[i] {
+			index[keyword] = append(index[keyword], i)
+		}
+	}
+	m.prefilter = newAhoCorasick(index)
+	return m
+}
+
+// filePatterns - indexes of line and multiline patterns for file and whether any of them has keywords
+func (m *matcher) filePatterns(repoFilePath string) ([]int, []int, bool) {
+	lineResult, multilineResult := []int{}, []int{}
+	withKeywords := false
+	for i := range m.patterns {
+		if m.patterns[i].KeyNameRe != nil || !m.patterns[i].matchFile(repoFilePath) {
+			continue
+		}
+		if m.patterns[i].Multiline {
+			multilineResult = append(multilineResult, i)
+		} else {
+			lineResult = append(lineResult, i)
+		}
+		withKeywords = withKeywords || m.keywords[i] != nil
+	}
+	return lineResult, multilineResult, withKeywords
+}
+
+// patternKeywords - declared keywords or literals one of which must be in any line matched by pattern
+func patternKeywords(p patternType) []string {
+	if len(p.Keywords) > 0 {
+		return p.Keywords
+	}
+	for _, re := range []*regexp.Regexp{p.ContentRe, p.KeyRe} {
+		if re == nil || re == matchAllRegex {
+			continue
+		}
+		if keywords := extractKeywords(re); keywords != nil {
+			return keywords
+		}
+	}
+	return nil
+}
+
+// extractKeywords - get lower case literals one of which is required by regexp
+// Unicode case folding of ASCII letters (e.g. 'ſ' for 's') is not taken into account
+func extractKeywords(re *regexp.Regexp) []string {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	keywords := requiredLiterals(parsed.Simplify())
+	if shortestLength(keywords) < minKeywordLength {
+		return nil
+	}
+	return keywords
+}
+
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		literal := string(re.Rune)
+		if re.Flags&syntax.FoldCase != 0 && !isASCII(literal) {
+			return nil
+		}
+		return []string{lowerASCII(literal)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var best []string
+		for _, sub := range re.Sub {
+			literals := requiredLiterals(sub)
+			if literals == nil {
+				continue
+			}
+			if best == nil || shortestLength(literals) > shortestLength(best) {
+				best = literals
+			}
+		}
+		return best
+	case syntax.OpAlternate:
+		result := []string{}
+		for _, sub := range re.Sub {
+			literals := requiredLiterals(sub)
+			if literals == nil {
+				return nil
+			}
+			result = append(result, literals...)
+		}
+		return result
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func shortestLength(keywords []string) int {
+	if len(keywords) == 0 {
+		return 0
+	}
+	result := len(keywords[0])
+	for _, keyword := range keywords[1:] {
+		if len(keyword) < result {
+			result = len(keyword)
+		}
+	}
+	return result
+}
+
+// strongOnly - active patterns which can't be excluded by .hungryfox.yml of repo
+func (m *matcher) strongOnly(active []int) []int {
+	result := []int{}
+	for _, i := range active {
+		if m.patterns[i].Strong {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// structuredPatterns - indexes of key/value patterns for config file of known format
+func (m *matcher) structuredPatterns(repoFilePath, format string) []int {
+	result := []int{}
+	if format == "" {
+		return result
+	}
+	for i := range m.patterns {
+		if m.patterns[i].KeyNameRe != nil && m.patterns[i].matchFile(repoFilePath) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
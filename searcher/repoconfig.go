@@ -0,0 +1,83 @@
+package searcher
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/config"
+)
+
+// repoRules - filters, excludes and owners from .hungryfox.yml of repo, they are not applied to strong patterns
+type repoRules struct {
+	filters []patternType
+	exclude []string
+	owners  []string
+}
+
+// SetRepoConfig - apply .hungryfox.yml of repo to its next diffs, nil removes previous one
+func (s *Searcher) SetRepoConfig(repoURL string, repoConfig *config.RepoConfig) error {
+	var rules *repoRules
+	if repoConfig != nil {
+		filters, err := compileFilters(repoConfig.Filters)
+		if err != nil {
+			return err
+		}
+		for _, glob := range repoConfig.Exclude {
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("bad exclude glob '%s': %v", glob, err)
+			}
+		}
+		rules = &repoRules{
+			filters: filters,
+			exclude: repoConfig.Exclude,
+			owners:  repoConfig.Owners,
+		}
+	}
+	s.rulesMutex.Lock()
+	defer s.rulesMutex.Unlock()
+	if s.repoRules == nil {
+		s.repoRules = map[string]*repoRules{}
+	}
+	if rules == nil {
+		delete(s.repoRules, repoURL)
+		return nil
+	}
+	s.repoRules[repoURL] = rules
+	return nil
+}
+
+// excluded - file or one of its directories matches exclude glob
+func (r *repoRules) excluded(filePath string) bool {
+	if r == nil {
+		return false
+	}
+	for _, glob := range r.exclude {
+		for p := strings.Trim(filePath, "/"); p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			if ok, _ := path.Match(strings.Trim(glob, "/"), p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apply - add owners to leak, false if leak of not strong pattern matches one of repo filters
+func (r *repoRules) apply(pattern *patternType, leak *hungryfox.Leak) bool {
+	if r == nil {
+		return true
+	}
+	leak.Owners = r.owners
+	if pattern.Strong {
+		return true
+	}
+	now := time.Now()
+	for i := range r.filters {
+		if r.filters[i].filter(*leak, now) {
+			return false
+		}
+	}
+	return true
+}
@@ -3,14 +3,17 @@ package searcher
 import (
 	"fmt"
 	"io/ioutil"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	sync "github.com/sasha-s/go-deadlock"
 
 	"github.com/AlexAkulov/hungryfox"
 	"github.com/AlexAkulov/hungryfox/config"
+	"github.com/AlexAkulov/hungryfox/metrics"
 
 	"github.com/rs/zerolog"
 	"gopkg.in/tomb.v2"
@@ -20,9 +23,97 @@ import (
 var matchAllRegex = regexp.MustCompile(".+")
 
 type patternType struct {
-	Name      string
-	ContentRe *regexp.Regexp
-	FileRe    *regexp.Regexp
+	Name          string
+	ContentRe     *regexp.Regexp
+	FileRe        *regexp.Regexp
+	ExcludeFileRe *regexp.Regexp
+	KeyRe         *regexp.Regexp
+	Entropy       *entropyType
+	Keywords      []string
+	Multiline     bool
+	KeyNameRe     *regexp.Regexp
+	ValueRe       *regexp.Regexp
+
+	Severity    hungryfox.Severity
+	Tags        []string
+	Description string
+	Remediation string
+	Strong      bool
+
+	// scope of filter
+	RepoGlob    string
+	PatternName string
+	Commit      string
+	AuthorEmail string
+	Expires     string
+
+	secretIndex int
+	expiresAt   time.Time
+}
+
+// secretGroup - name of regexp group which contains secret
+const secretGroup = "secret"
+
+// ignoreMarkerText - comment in code which suppresses leaks on its line and the line below,
+// it can be limited by pattern name like hungryfox:ignore pattern="AWS Access Key ID"
+const ignoreMarkerText = "hungryfox:ignore"
+
+var ignoreMarkerRe = regexp.MustCompile(ignoreMarkerText + `\b(?:[ \t]+pattern=(?:"([^"]*)"|([^\s"]+)))?`)
+
+// matchLine - check content regexp, key regexp and random-looking token after the key
+// and get position of secret in line
+func (p *patternType) matchLine(line string) (int, int, bool) {
+	var loc []int
+	if p.secretIndex > 0 {
+		loc = p.ContentRe.FindStringSubmatchIndex(line)
+	} else {
+		loc = p.ContentRe.FindStringIndex(line)
+	}
+	if loc == nil {
+		return 0, 0, false
+	}
+	start, end := loc[0], loc[1]
+	if p.secretIndex > 0 && loc[2*p.secretIndex] >= 0 {
+		start, end = loc[2*p.secretIndex], loc[2*p.secretIndex+1]
+	}
+	offset := 0
+	if p.KeyRe != nil {
+		keyLoc := p.KeyRe.FindStringIndex(line)
+		if keyLoc == nil {
+			return 0, 0, false
+		}
+		offset = keyLoc[1]
+	}
+	if p.Entropy != nil {
+		tokenStart, tokenEnd, ok := p.Entropy.FindToken(line[offset:])
+		return offset + tokenStart, offset + tokenEnd, ok
+	}
+	return start, end, true
+}
+
+// rule - description of pattern for leak report
+func (p *patternType) rule() string {
+	if p.KeyNameRe != nil {
+		rule := fmt.Sprintf("key_name(%s)", p.KeyNameRe.String())
+		if p.ValueRe != nil {
+			rule = fmt.Sprintf("%s value(%s)", rule, p.ValueRe.String())
+		}
+		if p.Entropy != nil {
+			rule = fmt.Sprintf("%s %s", rule, p.Entropy.String())
+		}
+		return rule
+	}
+	if p.Entropy == nil {
+		return p.ContentRe.String()
+	}
+	rule := p.Entropy.String()
+	if p.KeyRe != nil {
+		rule = fmt.Sprintf("key(%s) %s", p.KeyRe.String(), rule)
+	}
+	if p.ContentRe != matchAllRegex {
+		rule = fmt.Sprintf("%s %s", p.ContentRe.String(), rule)
+	}
+	return rule
 }
 
 type RepoStats struct {
@@ -37,21 +128,88 @@ type Searcher struct {
 	Log         zerolog.Logger
 
 	config           *config.Config
+	rulesMutex       sync.RWMutex
 	stats            map[string]RepoStats
 	statsMutex       sync.RWMutex
 	tomb             tomb.Tomb
 	patterns         []patternType
+	matcher          *matcher
+	contextLines     int
+	decodeMinLength  int
 	filters          []patternType
+	knownSecrets     knownSecrets
+	repoRules        map[string]*repoRules
 	updateConfigChan chan *config.Config
 }
 
 func compilePatterns(configPatterns []config.Pattern) ([]patternType, error) {
+	return compileRules(configPatterns, false)
+}
+
+func compileFilters(configPatterns []config.Pattern) ([]patternType, error) {
+	return compileRules(configPatterns, true)
+}
+
+// checkPatternFields - scope is only for filters and pattern without matcher matches every line
+func checkPatternFields(configPattern config.Pattern) error {
+	if configPattern.Repo != "" || configPattern.Pattern != "" || configPattern.Commit != "" || configPattern.AuthorEmail != "" || configPattern.Expires != "" {
+		return fmt.Errorf("pattern '%s' can't have repo, pattern, commit, author_email or expires, they are only for filters", configPattern.Name)
+	}
+	if configPattern.File == "" && configPattern.Content == "" && configPattern.Key == "" && configPattern.Entropy == nil && configPattern.KeyName == "" {
+		return fmt.Errorf("pattern '%s' needs file, content, key, entropy or key_name", configPattern.Name)
+	}
+	return nil
+}
+
+func compileRules(configPatterns []config.Pattern, filters bool) ([]patternType, error) {
 	result := make([]patternType, 0)
 	for _, configPattern := range configPatterns {
+		if !filters {
+			if err := checkPatternFields(configPattern); err != nil {
+				return nil, err
+			}
+		}
 		p := patternType{
 			Name:      configPattern.Name,
 			FileRe:    matchAllRegex,
 			ContentRe: matchAllRegex,
+			Keywords:  configPattern.Keywords,
+			Multiline: configPattern.Multiline,
+
+			Severity:    hungryfox.SeverityMedium,
+			Tags:        configPattern.Tags,
+			Description: configPattern.Description,
+			Remediation: configPattern.Remediation,
+			Strong:      configPattern.Strong,
+
+			RepoGlob:    configPattern.Repo,
+			PatternName: configPattern.Pattern,
+			Commit:      strings.ToLower(configPattern.Commit),
+			AuthorEmail: strings.ToLower(configPattern.AuthorEmail),
+			Expires:     configPattern.Expires,
+		}
+		if err := p.compileScope(); err != nil {
+			return nil, fmt.Errorf("pattern '%s' has %v", configPattern.Name, err)
+		}
+		if configPattern.Severity != "" {
+			p.Severity = hungryfox.Severity(configPattern.Severity)
+			if !p.Severity.IsValid() {
+				return nil, fmt.Errorf("pattern '%s' has unknown severity '%s'", configPattern.Name, configPattern.Severity)
+			}
+		}
+		if configPattern.Multiline && (configPattern.Key != "" || configPattern.Entropy != nil) {
+			return nil, fmt.Errorf("pattern '%s' can't be multiline with key or entropy", configPattern.Name)
+		}
+		if configPattern.KeyName == "" && configPattern.Value != "" {
+			return nil, fmt.Errorf("pattern '%s' has value without key_name", configPattern.Name)
+		}
+		if configPattern.KeyName != "" && (configPattern.Multiline || configPattern.Content != "" || configPattern.Key != "" || len(configPattern.Keywords) > 0) {
+			return nil, fmt.Errorf("pattern '%s' with key_name can't have content, key, keywords or be multiline", configPattern.Name)
+		}
+		for _, keyword := range configPattern.Keywords {
+			if keyword == "" {
+				return nil, fmt.Errorf("pattern '%s' has empty keyword", configPattern.Name)
+			}
 		}
 		if configPattern.File != "*" && configPattern.File != "" {
 			var err error
@@ -59,19 +217,133 @@ func compilePatterns(configPatterns []config.Pattern) ([]patternType, error) {
 				return nil, fmt.Errorf("can't compile pattern file regexp '%s' with: %v", configPattern.File, err)
 			}
 		}
+		if configPattern.ExcludeFile != "" {
+			var err error
+			if p.ExcludeFileRe, err = regexp.Compile(configPattern.ExcludeFile); err != nil {
+				return nil, fmt.Errorf("can't compile pattern exclude_file regexp '%s' with: %v", configPattern.ExcludeFile, err)
+			}
+		}
 		if configPattern.Content != "*" && configPattern.Content != "" {
 			var err error
 			if p.ContentRe, err = regexp.Compile(configPattern.Content); err != nil {
 				return nil, fmt.Errorf("can't compile pattern content regexp '%s' with: %v", configPattern.Content, err)
 			}
+			p.secretIndex = p.ContentRe.SubexpIndex(secretGroup)
+		}
+		if configPattern.Key != "" {
+			var err error
+			if p.KeyRe, err = regexp.Compile(configPattern.Key); err != nil {
+				return nil, fmt.Errorf("can't compile pattern key regexp '%s' with: %v", configPattern.Key, err)
+			}
+		}
+		if configPattern.KeyName != "" {
+			var err error
+			if p.KeyNameRe, err = regexp.Compile(configPattern.KeyName); err != nil {
+				return nil, fmt.Errorf("can't compile pattern key_name regexp '%s' with: %v", configPattern.KeyName, err)
+			}
+		}
+		if configPattern.Value != "" {
+			var err error
+			if p.ValueRe, err = regexp.Compile(configPattern.Value); err != nil {
+				return nil, fmt.Errorf("can't compile pattern value regexp '%s' with: %v", configPattern.Value, err)
+			}
+		}
+		var err error
+		if p.Entropy, err = compileEntropy(configPattern.Entropy); err != nil {
+			return nil, fmt.Errorf("can't compile pattern '%s' with: %v", configPattern.Name, err)
 		}
 		result = append(result, p)
 	}
 	return result, nil
 }
 
+func (p *patternType) compileScope() error {
+	if _, err := path.Match(p.RepoGlob, ""); err != nil {
+		return fmt.Errorf("bad repo glob '%s': %v", p.RepoGlob, err)
+	}
+	if _, err := path.Match(p.AuthorEmail, ""); err != nil {
+		return fmt.Errorf("bad author_email glob '%s': %v", p.AuthorEmail, err)
+	}
+	if p.Expires == "" {
+		return nil
+	}
+	var err error
+	if p.expiresAt, err = time.Parse("2006-01-02", p.Expires); err == nil {
+		return nil
+	}
+	if p.expiresAt, err = time.Parse(time.RFC3339, p.Expires); err != nil {
+		return fmt.Errorf("bad expires '%s', date like 2006-01-02 or RFC3339 time is expected", p.Expires)
+	}
+	return nil
+}
+
+// expired - filter isn't applied anymore
+func (p *patternType) expired(now time.Time) bool {
+	return !p.expiresAt.IsZero() && !now.Before(p.expiresAt)
+}
+
+// filter - check that leak is in scope of filter and matches it
+func (p *patternType) filter(leak hungryfox.Leak, now time.Time) bool {
+	if p.expired(now) {
+		return false
+	}
+	if p.PatternName != "" && p.PatternName != leak.PatternName {
+		return false
+	}
+	if p.Commit != "" && !strings.HasPrefix(strings.ToLower(leak.CommitHash), p.Commit) {
+		return false
+	}
+	if p.RepoGlob != "" {
+		if ok, _ := path.Match(p.RepoGlob, leak.RepoURL); !ok {
+			return false
+		}
+	}
+	if p.AuthorEmail != "" {
+		if ok, _ := path.Match(p.AuthorEmail, strings.ToLower(leak.CommitEmail)); !ok {
+			return false
+		}
+	}
+	return p.matchFile(fmt.Sprintf("%s/%s", leak.RepoURL, leak.FilePath)) && p.ContentRe.MatchString(leak.LeakString)
+}
+
+// matchFile - path matches file regexp and doesn't match exclude_file regexp
+func (p *patternType) matchFile(repoFilePath string) bool {
+	return p.FileRe.MatchString(repoFilePath) && (p.ExcludeFileRe == nil || !p.ExcludeFileRe.MatchString(repoFilePath))
+}
+
+// ValidatePattern - check that pattern can be compiled
+func ValidatePattern(configPattern config.Pattern) error {
+	_, err := compilePatterns([]config.Pattern{configPattern})
+	return err
+}
+
+// ValidateFilter - check that filter can be compiled
+func ValidateFilter(configPattern config.Pattern) error {
+	_, err := compileFilters([]config.Pattern{configPattern})
+	return err
+}
+
+// Update - apply configuration in the searcher loop, previous pending configuration is replaced
 func (s *Searcher) Update(conf *config.Config) {
-	s.updateConfigChan <- conf
+	if s.updateConfigChan == nil {
+		return
+	}
+	for {
+		select {
+		case s.updateConfigChan <- conf:
+			return
+		default:
+		}
+		select {
+		case <-s.updateConfigChan:
+		default:
+		}
+	}
+}
+
+// Reload - apply configuration immediately and return its error
+func (s *Searcher) Reload(conf *config.Config) error {
+	return s.updateConfig(conf)
 }
 
 func (s *Searcher) Start(conf *config.Config) error {
@@ -103,23 +375,18 @@ func (s *Searcher) worker() error {
 			return nil
 		case diff := <-s.DiffChannel:
 			leaks := s.GetLeaks(*diff)
+			metrics.DiffsProcessed.Inc()
 			filtredLeaks := 0
 			for i := range leaks {
 				if s.filterLeak(leaks[i]) {
 					filtredLeaks++
+					metrics.LeaksFiltred.WithLabelValues(leaks[i].PatternName).Inc()
 					continue
 				}
+				metrics.LeaksFound.WithLabelValues(leaks[i].PatternName).Inc()
 				s.LeakChannel <- &leaks[i]
 			}
-			leaksCount := len(leaks) - filtredLeaks
-			if leaksCount > 0 || filtredLeaks > 0 {
-				s.statsMutex.Lock()
-				repoStats, _ := s.stats[diff.RepoURL]
-				repoStats.LeaksFiltred += filtredLeaks
-				repoStats.LeaksFound += leaksCount
-				s.stats[diff.RepoURL] = repoStats
-				s.statsMutex.Unlock()
-			}
+			s.addStats(diff.RepoURL, len(leaks)-filtredLeaks, filtredLeaks)
 		}
 	}
 }
@@ -129,7 +396,7 @@ func (s *Searcher) Stop() error {
 	return s.tomb.Wait()
 }
 
-func loadPatternsFromFile(file string) ([]patternType, error) {
+func readPatternsFile(file string) ([]config.Pattern, error) {
 	rawPatterns := []config.Pattern{}
 	rawData, err := ioutil.ReadFile(file)
 	if err != nil {
@@ -138,21 +405,29 @@ func loadPatternsFromFile(file string) ([]patternType, error) {
 	if err := yaml.Unmarshal(rawData, &rawPatterns); err != nil {
 		return nil, fmt.Errorf("can't parse file '%s' with: %v", file, err)
 	}
-	result, err := compilePatterns(rawPatterns)
+	return rawPatterns, nil
+}
+
+func loadPatternsFromFile(file string, filters bool) ([]patternType, error) {
+	rawPatterns, err := readPatternsFile(file)
+	if err != nil {
+		return nil, err
+	}
+	result, err := compileRules(rawPatterns, filters)
 	if err != nil {
 		return nil, fmt.Errorf("can't compile file '%s' with: %v", file, err)
 	}
 	return result, nil
 }
 
-func loadPatternsFromPath(path string) ([]patternType, error) {
+func loadPatternsFromPath(path string, filters bool) ([]patternType, error) {
 	result := []patternType{}
 	files, err := filepath.Glob(path)
 	if err != nil {
 		return nil, err
 	}
 	for _, file := range files {
-		patterns, err := loadPatternsFromFile(file)
+		patterns, err := loadPatternsFromFile(file, filters)
 		if err != nil {
 			return nil, err
 		}
@@ -166,13 +441,13 @@ func (s *Searcher) updateConfig(conf *config.Config) error {
 	if err != nil {
 		return err
 	}
-	newCompiledFiltres, err := compilePatterns(conf.Filters)
+	newCompiledFiltres, err := compileFilters(conf.Filters)
 	if err != nil {
 		return err
 	}
 
 	if conf.Common.PatternsPath != "" {
-		newFilePatterns, err := loadPatternsFromPath(conf.Common.PatternsPath)
+		newFilePatterns, err := loadPatternsFromPath(conf.Common.PatternsPath, false)
 		if err != nil {
 			return err
 		}
@@ -180,18 +455,113 @@ func (s *Searcher) updateConfig(conf *config.Config) error {
 	}
 
 	if conf.Common.FiltresPath != "" {
-		newFileFilters, err := loadPatternsFromPath(conf.Common.FiltresPath)
+		newFileFilters, err := loadPatternsFromPath(conf.Common.FiltresPath, true)
 		if err != nil {
 			return err
 		}
 		newCompiledFiltres = append(newCompiledFiltres, newFileFilters...)
 	}
+	newKnownSecrets := knownSecrets{}
+	if conf.Common.KnownSecretsPath != "" {
+		if newKnownSecrets, err = loadKnownSecrets(conf.Common.KnownSecretsPath); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	for _, filter := range newCompiledFiltres {
+		if filter.expired(now) {
+			s.Log.Warn().Str("filter", filter.Name).Str("expires", filter.Expires).Msg("filter is expired")
+		}
+	}
+	s.rulesMutex.Lock()
 	s.patterns, s.filters = newCompiledPatterns, newCompiledFiltres
-	s.Log.Info().Int("patterns", len(newCompiledPatterns)).Int("filters", len(newCompiledFiltres)).Msg("loaded")
+	s.knownSecrets = newKnownSecrets
+	s.matcher = newMatcher(newCompiledPatterns)
+	s.contextLines = conf.Common.ContextLines
+	s.decodeMinLength = conf.Common.DecodeMinLength
 	s.config = conf
+	s.rulesMutex.Unlock()
+	s.Log.Info().Int("patterns", len(newCompiledPatterns)).Int("filters", len(newCompiledFiltres)).Int("known_secrets", len(newKnownSecrets)).Msg("loaded")
 	return nil
 }
 
+// Config - get current configuration
+func (s *Searcher) Config() *config.Config {
+	s.rulesMutex.RLock()
+	defer s.rulesMutex.RUnlock()
+	return s.config
+}
+
+// Patterns - get loaded patterns
+func (s *Searcher) Patterns() []config.Pattern {
+	s.rulesMutex.RLock()
+	defer s.rulesMutex.RUnlock()
+	return convertPatterns(s.patterns)
+}
+
+// Filters - get loaded filters
+func (s *Searcher) Filters() []config.Pattern {
+	s.rulesMutex.RLock()
+	defer s.rulesMutex.RUnlock()
+	return convertPatterns(s.filters)
+}
+
+func convertPatterns(patterns []patternType) []config.Pattern {
+	result := make([]config.Pattern, 0, len(patterns))
+	for _, p := range patterns {
+		configPattern := config.Pattern{
+			Name:      p.Name,
+			File:      p.FileRe.String(),
+			Content:   p.ContentRe.String(),
+			Entropy:   p.Entropy.config(),
+			Keywords:  p.Keywords,
+			Multiline: p.Multiline,
+
+			Severity:    string(p.Severity),
+			Tags:        p.Tags,
+			Description: p.Description,
+			Remediation: p.Remediation,
+			Strong:      p.Strong,
+
+			Repo:        p.RepoGlob,
+			Pattern:     p.PatternName,
+			Commit:      p.Commit,
+			AuthorEmail: p.AuthorEmail,
+			Expires:     p.Expires,
+		}
+		if p.KeyRe != nil {
+			configPattern.Key = p.KeyRe.String()
+		}
+		if p.ExcludeFileRe != nil {
+			configPattern.ExcludeFile = p.ExcludeFileRe.String()
+		}
+		if p.KeyNameRe != nil {
+			configPattern.KeyName = p.KeyNameRe.String()
+			configPattern.Content = ""
+		}
+		if p.ValueRe != nil {
+			configPattern.Value = p.ValueRe.String()
+		}
+		result = append(result, configPattern)
+	}
+	return result
+}
+
+func (s *Searcher) addStats(repoURL string, found, filtred int) {
+	if found == 0 && filtred == 0 {
+		return
+	}
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	if s.stats == nil {
+		s.stats = map[string]RepoStats{}
+	}
+	repoStats := s.stats[repoURL]
+	repoStats.LeaksFiltred += filtred
+	repoStats.LeaksFound += found
+	s.stats[repoURL] = repoStats
+}
+
 func (s *Searcher) Status(repoURL string) RepoStats {
 	s.statsMutex.RLock()
 	defer s.statsMutex.RUnlock()
@@ -201,40 +571,335 @@ func (s *Searcher) Status(repoURL string) RepoStats {
 	return RepoStats{}
 }
 
+// GetLeaks - find leaks in diff, leaks marked by hungryfox:ignore comment
+// or filtered by .hungryfox.yml of repo are counted as filtred
 func (s *Searcher) GetLeaks(diff hungryfox.Diff) []hungryfox.Leak {
+	s.rulesMutex.RLock()
+	defer s.rulesMutex.RUnlock()
 	leaks := make([]hungryfox.Leak, 0)
+	filtred := 0
+	defer func() { s.addStats(diff.RepoURL, 0, filtred) }()
+	m := s.matcher
+	if m == nil {
+		m = newMatcher(s.patterns)
+	}
+	lineActive, multilineActive, prefilter := m.filePatterns(diff.RepoURL + "/" + diff.FilePath)
+	structuredActive := m.structuredPatterns(diff.RepoURL+"/"+diff.FilePath, keyValueFormat(diff.FilePath))
+	rules := s.repoRules[diff.RepoURL]
+	if rules.excluded(diff.FilePath) {
+		lineActive, multilineActive = m.strongOnly(lineActive), m.strongOnly(multilineActive)
+		structuredActive = m.strongOnly(structuredActive)
+	}
+	if len(lineActive) == 0 && len(multilineActive) == 0 && len(structuredActive) == 0 && len(s.knownSecrets) == 0 {
+		return leaks
+	}
 	lines := strings.Split(diff.Content, "\n")
-	for _, line := range lines {
-		for _, pattern := range s.patterns {
-			repoFilePath := fmt.Sprintf("%s/%s", diff.RepoURL, diff.FilePath)
-			if !pattern.FileRe.MatchString(repoFilePath) {
+	// keepLeak - check hungryfox:ignore comment and .hungryfox.yml of repo, skipped leak is counted as filtred
+	keepLeak := func(pattern *patternType, leak *hungryfox.Leak, n int) bool {
+		if ignoreMarker(lines, n, n, pattern.Name) || !rules.apply(pattern, leak) {
+			metrics.LeaksFiltred.WithLabelValues(pattern.Name).Inc()
+			filtred++
+			return false
+		}
+		return true
+	}
+	candidates := make([]bool, len(m.patterns))
+	matched := []int{}
+	for n, line := range lines {
+		if len(lineActive) == 0 && s.decodeMinLength < 1 && len(s.knownSecrets) == 0 {
+			break
+		}
+		if prefilter {
+			for _, i := range lineActive {
+				candidates[i] = false
+			}
+			m.prefilter.Match(line, candidates)
+		}
+		matched = matched[:0]
+		for _, i := range lineActive {
+			pattern := &m.patterns[i]
+			if m.keywords[i] != nil && !candidates[i] {
 				continue
 			}
-			if pattern.ContentRe.MatchString(line) {
-				if len(line) > 1024 {
-					line = line[:1024]
+			if start, end, ok := pattern.matchLine(line); ok {
+				matched = append(matched, i)
+				leak := newLeak(diff, pattern, lines, n, start, end, s.contextLines)
+				if keepLeak(pattern, &leak, n) {
+					leaks = append(leaks, leak)
+				}
+			}
+		}
+		// known secret is exact match of real credential, so it is never ignored or filtered
+		for _, match := range s.knownSecrets.find(line) {
+			leak := newKnownSecretLeak(diff, lines, n, match, s.contextLines)
+			rules.apply(match.pattern(), &leak)
+			leaks = append(leaks, leak)
+		}
+		if s.decodeMinLength < 1 {
+			continue
+		}
+		for _, blob := range decodeBlobs(line, s.decodeMinLength) {
+			decoded := blob.decodedLine(line)
+			if prefilter {
+				for _, active := range [][]int{lineActive, multilineActive} {
+					for _, i := range active {
+						candidates[i] = false
+					}
 				}
-				leaks = append(leaks, hungryfox.Leak{
-					RepoPath:     diff.RepoPath,
-					FilePath:     diff.FilePath,
-					PatternName:  pattern.Name,
-					Regexp:       pattern.ContentRe.String(),
-					LeakString:   line,
-					CommitHash:   diff.CommitHash,
-					TimeStamp:    diff.TimeStamp,
-					CommitAuthor: diff.Author,
-					CommitEmail:  diff.AuthorEmail,
-					RepoURL:      diff.RepoURL,
-				})
+				m.prefilter.Match(decoded, candidates)
+			}
+			for _, active := range [][]int{lineActive, multilineActive} {
+				for _, i := range active {
+					pattern := &m.patterns[i]
+					if m.keywords[i] != nil && !candidates[i] || containsInt(matched, i) {
+						continue
+					}
+					if leak, ok := findDecoded(diff, pattern, lines, n, blob, decoded, s.contextLines); ok {
+						matched = append(matched, i)
+						if keepLeak(pattern, &leak, n) {
+							leaks = append(leaks, leak)
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(structuredActive) > 0 {
+		keyValues := parseKeyValues(keyValueFormat(diff.FilePath), lines)
+		for _, i := range structuredActive {
+			pattern := &m.patterns[i]
+			for _, kv := range keyValues {
+				if !pattern.matchKeyValue(kv) {
+					continue
+				}
+				leak := newKeyValueLeak(diff, pattern, lines, kv, s.contextLines)
+				if keepLeak(pattern, &leak, kv.Line) {
+					leaks = append(leaks, leak)
+				}
+			}
+		}
+	}
+	if len(multilineActive) == 0 {
+		return leaks
+	}
+	if prefilter {
+		for _, i := range multilineActive {
+			candidates[i] = false
+		}
+		m.prefilter.Match(diff.Content, candidates)
+	}
+	for _, i := range multilineActive {
+		if m.keywords[i] != nil && !candidates[i] {
+			continue
+		}
+		pattern := &m.patterns[i]
+		found, ignored := findMultiline(diff, pattern, lines, s.contextLines)
+		for j := range found {
+			if !rules.apply(pattern, &found[j]) {
+				ignored++
+				continue
 			}
+			leaks = append(leaks, found[j])
+		}
+		if ignored > 0 {
+			metrics.LeaksFiltred.WithLabelValues(pattern.Name).Add(float64(ignored))
+			filtred += ignored
 		}
 	}
 	return leaks
 }
 
+// findDecoded - leak found by pattern in line n with decoded encoded substring,
+// the encoded substring is secret of leak
+func findDecoded(diff hungryfox.Diff, pattern *patternType, lines []string, n int, blob decodedBlob, decoded string, contextLines int) (hungryfox.Leak, bool) {
+	secret := ""
+	if pattern.Multiline {
+		loc := pattern.ContentRe.FindStringSubmatchIndex(decoded)
+		if loc == nil || loc[0] == loc[1] {
+			return hungryfox.Leak{}, false
+		}
+		secret = decoded[loc[0]:loc[1]]
+		if pattern.secretIndex > 0 && loc[2*pattern.secretIndex] >= 0 {
+			secret = decoded[loc[2*pattern.secretIndex]:loc[2*pattern.secretIndex+1]]
+		}
+	} else {
+		found := false
+		for _, line := range strings.Split(decoded, "\n") {
+			if start, end, ok := pattern.matchLine(line); ok {
+				secret, found = line[start:end], true
+				break
+			}
+		}
+		if !found {
+			return hungryfox.Leak{}, false
+		}
+	}
+	leak := newLeak(diff, pattern, lines, n, blob.Start, blob.End, contextLines)
+	leak.SecretHash = hungryfox.SecretHash(secret)
+	leak.Encoding = blob.Encoding
+	return leak, true
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreMarker - check hungryfox:ignore comment for pattern on lines from first to last or on the line above them
+func ignoreMarker(lines []string, first, last int, patternName string) bool {
+	for n := maxInt(0, first-1); n <= last && n < len(lines); n++ {
+		if !strings.Contains(lines[n], ignoreMarkerText) {
+			continue
+		}
+		for _, marker := range ignoreMarkerRe.FindAllStringSubmatch(lines[n], -1) {
+			name := marker[1] + marker[2]
+			if name == "" || strings.EqualFold(name, patternName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findMultiline - leaks found by pattern in whole content of diff and count of ignored ones
+func findMultiline(diff hungryfox.Diff, pattern *patternType, lines []string, contextLines int) ([]hungryfox.Leak, int) {
+	result := []hungryfox.Leak{}
+	ignored := 0
+	for _, loc := range pattern.ContentRe.FindAllStringSubmatchIndex(diff.Content, -1) {
+		start, end := loc[0], loc[1]
+		if start == end {
+			continue
+		}
+		secretStart, secretEnd := start, end
+		if pattern.secretIndex > 0 && loc[2*pattern.secretIndex] >= 0 {
+			secretStart, secretEnd = loc[2*pattern.secretIndex], loc[2*pattern.secretIndex+1]
+		}
+		first := strings.Count(diff.Content[:start], "\n")
+		last := first + strings.Count(strings.TrimSuffix(diff.Content[start:end], "\n"), "\n")
+		if ignoreMarker(lines, first, last, pattern.Name) {
+			ignored++
+			continue
+		}
+		leak := newLeak(diff, pattern, lines, first, 0, 0, contextLines)
+		leak.LeakString = truncateLine(diff.Content[start:end])
+		leak.SecretHash = hungryfox.SecretHash(diff.Content[secretStart:secretEnd])
+		leak.SecretStart, leak.SecretEnd = secretStart-start, secretEnd-start
+		if leak.SecretEnd > len(leak.LeakString) {
+			leak.SecretStart, leak.SecretEnd = 0, 0
+		}
+		if leak.Line > 0 {
+			leak.LineEnd = lineNumber(diff, last)
+		}
+		leak.ContextAfter = nil
+		if contextLines > 0 {
+			leak.ContextAfter = contextAfter(lines, last, contextLines)
+		}
+		result = append(result, leak)
+	}
+	return result, ignored
+}
+
+// newLeak - leak found in line n of diff with secret from start to end of line
+func newLeak(diff hungryfox.Diff, pattern *patternType, lines []string, n, start, end, contextLines int) hungryfox.Leak {
+	line := lines[n]
+	leakString := truncateLine(line)
+	secretHash := hungryfox.SecretHash(line[start:end])
+	if end > len(leakString) {
+		start, end = 0, 0
+	}
+	leak := hungryfox.Leak{
+		RepoPath:     diff.RepoPath,
+		FilePath:     diff.FilePath,
+		PatternName:  pattern.Name,
+		Regexp:       pattern.rule(),
+		LeakString:   leakString,
+		Line:         lineNumber(diff, n),
+		CommitHash:   diff.CommitHash,
+		TimeStamp:    diff.TimeStamp,
+		CommitAuthor: diff.Author,
+		CommitEmail:  diff.AuthorEmail,
+		RepoURL:      diff.RepoURL,
+		SecretStart:  start,
+		SecretEnd:    end,
+		SecretHash:   secretHash,
+		Severity:     pattern.Severity,
+		Tags:         pattern.Tags,
+		Description:  pattern.Description,
+		Remediation:  pattern.Remediation,
+	}
+	if contextLines > 0 {
+		leak.ContextBefore = truncateLines(lines[maxInt(0, n-contextLines):n])
+		leak.ContextAfter = contextAfter(lines, n, contextLines)
+	}
+	return leak
+}
+
+// contextAfter - lines after line n
+func contextAfter(lines []string, n, contextLines int) []string {
+	// content of chunk ends with new line
+	last := len(lines)
+	if last > 0 && lines[last-1] == "" {
+		last--
+	}
+	if n+1 >= last {
+		return nil
+	}
+	return truncateLines(lines[n+1 : minInt(last, n+1+contextLines)])
+}
+
+func truncateLine(line string) string {
+	if len(line) > 1024 {
+		return line[:1024]
+	}
+	return line
+}
+
+func truncateLines(lines []string) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, truncateLine(line))
+	}
+	return result
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// lineNumber - number of line in file, 0 if position of diff is unknown
+func lineNumber(diff hungryfox.Diff, index int) int {
+	if diff.LineBegin < 1 {
+		return 0
+	}
+	return diff.LineBegin + index
+}
+
 func (s *Searcher) filterLeak(leak hungryfox.Leak) bool {
-	for _, filter := range s.filters {
-		if filter.FileRe.MatchString(fmt.Sprintf("%s/%s", leak.RepoURL, leak.FilePath)) && filter.ContentRe.MatchString(leak.LeakString) {
+	if isKnownSecretLeak(leak) {
+		return false
+	}
+	s.rulesMutex.RLock()
+	defer s.rulesMutex.RUnlock()
+	now := time.Now()
+	for i := range s.filters {
+		if s.filters[i].filter(leak, now) {
 			return true
 		}
 	}
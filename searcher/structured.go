@@ -0,0 +1,226 @@
+package searcher
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/AlexAkulov/hungryfox"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// formats of key/value files
+const (
+	formatYAML       = "yaml"
+	formatJSON       = "json"
+	formatEnv        = "env"
+	formatProperties = "properties"
+	formatINI        = "ini"
+	formatTFVars     = "tfvars"
+)
+
+// keyValue - value of key in config file, Line is index of line in content
+type keyValue struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+var (
+	keyValueLineRe = regexp.MustCompile(`^\s*(?:export\s+|-\s+)?["']?([\w.\-/@\[\]]+)["']?\s*([=:])\s*(.*)$`)
+	iniSectionRe   = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*$`)
+	placeholderRe  = regexp.MustCompile(`(?i)^(\$[a-z_][a-z0-9_]*|%\([^)]*\)s|%[a-z_][a-z0-9_]*%|<[^<>]*>|\*+|x{3,}|\.+|-+|your[_-].*|.*_here)$`)
+)
+
+// placeholderWords - values which are used instead of real secrets in examples and templates
+var placeholderWords = map[string]bool{
+	"changeme": true, "change_me": true, "change-me": true, "changeit": true,
+	"password": true, "passwd": true, "secret": true, "token": true,
+	"example": true, "dummy": true, "placeholder": true, "redacted": true, "todo": true, "tbd": true,
+	"none": true, "null": true, "nil": true, "undefined": true,
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+}
+
+// isPlaceholder - value is empty, reference to variable, template or well-known fake
+func isPlaceholder(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" || placeholderWords[strings.ToLower(value)] {
+		return true
+	}
+	for _, template := range []string{"${", "{{", "#{", "$("} {
+		if strings.Contains(value, template) {
+			return true
+		}
+	}
+	return placeholderRe.MatchString(value)
+}
+
+// keyValueFormat - format of config file by its name, empty if it is not key/value file
+func keyValueFormat(filePath string) string {
+	base := strings.ToLower(path.Base(filePath))
+	switch ext := path.Ext(base); {
+	case ext == ".yml" || ext == ".yaml":
+		return formatYAML
+	case ext == ".json":
+		return formatJSON
+	case base == ".env" || strings.HasPrefix(base, ".env.") || ext == ".env":
+		return formatEnv
+	case ext == ".properties":
+		return formatProperties
+	case ext == ".ini" || ext == ".cfg":
+		return formatINI
+	case ext == ".tfvars":
+		return formatTFVars
+	}
+	return ""
+}
+
+// parseKeyValues - keys and values of added lines of config file,
+// YAML and JSON are parsed with full key paths if chunk is valid document, other formats line by line
+func parseKeyValues(format string, lines []string) []keyValue {
+	byLines := parseKeyValueLines(format, lines)
+	if format == formatYAML || format == formatJSON {
+		// part of document may be parsed without error but with lost keys
+		if result, ok := parseYAML(lines); ok && len(result) >= len(byLines) {
+			return result
+		}
+	}
+	return byLines
+}
+
+func parseYAML(lines []string) ([]keyValue, bool) {
+	document := yaml.MapSlice{}
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &document); err != nil || len(document) == 0 {
+		return nil, false
+	}
+	result := []keyValue{}
+	from := 0
+	var walk func(prefix, key string, value interface{})
+	walk = func(prefix, key string, value interface{}) {
+		switch v := value.(type) {
+		case yaml.MapSlice:
+			for _, item := range v {
+				itemKey := fmt.Sprint(item.Key)
+				walk(joinKey(prefix, itemKey), itemKey, item.Value)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(prefix, key, item)
+			}
+		case nil:
+		default:
+			text := fmt.Sprint(v)
+			from = findKeyLine(lines, key, text, from)
+			result = append(result, keyValue{Key: prefix, Value: text, Line: from})
+		}
+	}
+	walk("", "", yaml.MapSlice(document))
+	return result, true
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// findKeyLine - index of line with key and value starting from line from
+func findKeyLine(lines []string, key, value string, from int) int {
+	firstLine := strings.SplitN(value, "\n", 2)[0]
+	for _, withValue := range []bool{true, false} {
+		for n := from; n < len(lines); n++ {
+			if strings.Contains(lines[n], key) && (!withValue || strings.Contains(lines[n], firstLine)) {
+				return n
+			}
+		}
+	}
+	return from
+}
+
+func parseKeyValueLines(format string, lines []string) []keyValue {
+	result := []keyValue{}
+	section := ""
+	for n, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") ||
+			strings.HasPrefix(trimmed, "!") || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		if format == formatINI {
+			if m := iniSectionRe.FindStringSubmatch(line); m != nil {
+				section = strings.TrimSpace(m[1])
+				continue
+			}
+		}
+		m := keyValueLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		separator := m[2]
+		if (format == formatYAML || format == formatJSON) && separator != ":" || format == formatEnv && separator != "=" {
+			continue
+		}
+		value, ok := cleanValue(format, m[3])
+		if !ok {
+			continue
+		}
+		result = append(result, keyValue{Key: joinKey(section, m[1]), Value: value, Line: n})
+	}
+	return result
+}
+
+// cleanValue - value without quotes and inline comment, false if value is nested structure
+func cleanValue(format, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if format == formatJSON || format == formatTFVars {
+		value = strings.TrimSpace(strings.TrimSuffix(value, ","))
+	}
+	if value == "" || strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[") ||
+		format == formatYAML && (strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") || strings.HasPrefix(value, "&")) {
+		return "", false
+	}
+	if quote := value[0]; (quote == '"' || quote == '\'') && format != formatProperties {
+		if end := strings.IndexByte(value[1:], quote); end >= 0 {
+			return value[1 : end+1], true
+		}
+		return value[1:], true
+	}
+	if format != formatProperties {
+		for _, comment := range []string{" #", " ;", " //"} {
+			if i := strings.Index(value, comment); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+	}
+	return value, true
+}
+
+// matchKeyValue - key name matches pattern and value is literal which matches value regexp and entropy
+func (p *patternType) matchKeyValue(kv keyValue) bool {
+	if !p.KeyNameRe.MatchString(kv.Key) || isPlaceholder(kv.Value) {
+		return false
+	}
+	if p.ValueRe != nil && !p.ValueRe.MatchString(kv.Value) {
+		return false
+	}
+	if p.Entropy != nil {
+		if _, _, ok := p.Entropy.FindToken(kv.Value); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// newKeyValueLeak - leak found by structured pattern in config file, value is secret of leak
+func newKeyValueLeak(diff hungryfox.Diff, pattern *patternType, lines []string, kv keyValue, contextLines int) hungryfox.Leak {
+	start, end := 0, 0
+	if i := strings.Index(lines[kv.Line], kv.Value); i >= 0 {
+		start, end = i, i+len(kv.Value)
+	}
+	leak := newLeak(diff, pattern, lines, kv.Line, start, end, contextLines)
+	leak.SecretHash = hungryfox.SecretHash(kv.Value)
+	return leak
+}
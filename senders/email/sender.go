@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"net/smtp"
+	"sort"
 	"strings"
 
 	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/metrics"
 
 	"github.com/facebookgo/muster"
 	"gopkg.in/gomail.v2"
@@ -16,14 +18,64 @@ import (
 type mailTemplateStruct struct {
 	LeaksCount int
 	FilesCount int
+	Severities []severityCount
 	Repos      []*mailTemplateRepoStruct
 }
 
+type severityCount struct {
+	Severity hungryfox.Severity
+	Count    int
+}
+
 type mailTemplateRepoStruct struct {
 	RepoURL string
+	Owners  []string
 	Items   []hungryfox.Leak
 }
 
+// maxSeverity - severity of the most dangerous leak in repo
+func (r *mailTemplateRepoStruct) maxSeverity() int {
+	result := 0
+	for _, leak := range r.Items {
+		if leak.Severity.Rank() > result {
+			result = leak.Severity.Rank()
+		}
+	}
+	return result
+}
+
+// sortBySeverity - the most dangerous leaks and repos go first
+func (m *mailTemplateStruct) sortBySeverity() {
+	counts := map[hungryfox.Severity]int{}
+	for _, repo := range m.Repos {
+		sort.SliceStable(repo.Items, func(i, j int) bool {
+			a, b := repo.Items[i], repo.Items[j]
+			if a.Severity.Rank() != b.Severity.Rank() {
+				return a.Severity.Rank() > b.Severity.Rank()
+			}
+			if a.FilePath != b.FilePath {
+				return a.FilePath < b.FilePath
+			}
+			return a.Line < b.Line
+		})
+		for _, leak := range repo.Items {
+			counts[leak.Severity]++
+		}
+	}
+	sort.SliceStable(m.Repos, func(i, j int) bool {
+		if m.Repos[i].maxSeverity() != m.Repos[j].maxSeverity() {
+			return m.Repos[i].maxSeverity() > m.Repos[j].maxSeverity()
+		}
+		return m.Repos[i].RepoURL < m.Repos[j].RepoURL
+	})
+	m.Severities = nil
+	for _, severity := range []hungryfox.Severity{hungryfox.SeverityCritical, hungryfox.SeverityHigh, hungryfox.SeverityMedium, hungryfox.SeverityLow} {
+		if counts[severity] > 0 {
+			m.Severities = append(m.Severities, severityCount{Severity: severity, Count: counts[severity]})
+		}
+	}
+}
+
 func (s *Sender) batchMaker() muster.Batch {
 	return &batch{
 		Sender: s,
@@ -51,14 +103,16 @@ func (b *batch) Fire(notifier muster.Notifier) {
 	for _, repo := range b.Repos {
 		messageData.Repos = append(messageData.Repos, repo)
 	}
+	messageData.sortBySeverity()
 	err := b.Sender.sendMessage(b.Sender.AuditorEmail, messageData)
+	metrics.EmailMessages.WithLabelValues(metrics.Result(err)).Inc()
 	if err != nil {
 		b.Sender.Log.Error().Str("error", err.Error()).Msg("can't send email")
 	}
 }
 
 func (b *batch) Add(item interface{}) {
-	leak := item.(hungryfox.Leak)
+	leak := item.(hungryfox.Leak).Redact(b.Sender.Redaction)
 	leak.LeakString = strings.TrimSpace(leak.LeakString)
 	if len(leak.LeakString) > 512 {
 		leak.LeakString = "too long"
@@ -69,6 +123,9 @@ func (b *batch) Add(item interface{}) {
 			Items:   []hungryfox.Leak{},
 		}
 	}
+	if len(leak.Owners) > 0 {
+		b.Repos[leak.RepoURL].Owners = leak.Owners
+	}
 	b.Repos[leak.RepoURL].Items = append(b.Repos[leak.RepoURL].Items, leak)
 	b.Files[fmt.Sprintf("%s/%s", leak.RepoURL, leak.FilePath)] = struct{}{}
 	b.LeaksCount++
@@ -101,6 +158,9 @@ func (s *Sender) sendMessage(recipient string, messageData *mailTemplateStruct)
 	} else {
 		subject = fmt.Sprintf("Found %d leaks in %d repos", messageData.LeaksCount, len(messageData.Repos))
 	}
+	if len(messageData.Severities) > 0 {
+		subject = fmt.Sprintf("[%s] %s", messageData.Severities[0].Severity, subject)
+	}
 	m.SetHeader("Subject", subject)
 	m.AddAlternativeWriter("text/html", func(w io.Writer) error {
 		return s.template.Execute(w, messageData)
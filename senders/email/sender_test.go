@@ -0,0 +1,52 @@
+package email
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/AlexAkulov/hungryfox"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSortBySeverity(t *testing.T) {
+	Convey("Test sort leaks by severity", t, func() {
+		data := &mailTemplateStruct{
+			Repos: []*mailTemplateRepoStruct{
+				{RepoURL: "https://example.com/a", Items: []hungryfox.Leak{
+					{FilePath: "b.txt", Severity: hungryfox.SeverityLow},
+					{FilePath: "a.txt", Severity: hungryfox.SeverityMedium},
+				}},
+				{RepoURL: "https://example.com/b", Items: []hungryfox.Leak{
+					{FilePath: "c.txt", Severity: hungryfox.SeverityLow},
+					{FilePath: "d.txt", Line: 2, Severity: hungryfox.SeverityCritical},
+					{FilePath: "d.txt", Line: 1, Severity: hungryfox.SeverityCritical},
+				}},
+			},
+		}
+		data.sortBySeverity()
+		So(data.Repos[0].RepoURL, ShouldEqual, "https://example.com/b")
+		So(data.Repos[0].Items[0].Line, ShouldEqual, 1)
+		So(data.Repos[0].Items[1].Line, ShouldEqual, 2)
+		So(data.Repos[0].Items[2].FilePath, ShouldEqual, "c.txt")
+		So(data.Repos[1].Items[0].FilePath, ShouldEqual, "a.txt")
+		So(data.Severities, ShouldResemble, []severityCount{
+			{Severity: hungryfox.SeverityCritical, Count: 2},
+			{Severity: hungryfox.SeverityMedium, Count: 1},
+			{Severity: hungryfox.SeverityLow, Count: 2},
+		})
+
+		Convey("template is rendered", func() {
+			data.Repos[0].Items[0].Description = "Deploy key"
+			data.Repos[0].Items[0].Remediation = "https://example.com/howto"
+			data.Repos[0].Items[0].LeakString = "<secret>"
+			buf := &bytes.Buffer{}
+			So(template.Must(template.New("mail").Parse(defaultTemplate)).Execute(buf, data), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, "critical: 2, medium: 1, low: 2")
+			So(buf.String(), ShouldContainSubstring, `href="https://example.com/howto"`)
+			So(strings.Contains(buf.String(), "<secret>"), ShouldBeFalse)
+		})
+	})
+}
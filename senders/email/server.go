@@ -28,6 +28,7 @@ type Config struct {
 type Sender struct {
 	AuditorEmail string
 	Config       *Config
+	Redaction    hungryfox.Redaction
 	Log          zerolog.Logger
 	template     *template.Template
 	muster       *muster.Client
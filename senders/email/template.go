@@ -122,7 +122,7 @@ const defaultTemplate = `
               <p>Кажется, мы нашли что-то похожее на пароль, токен или ключ.</p>
               <p>Как удалить пароль из репозитория написано <a href="https://help.github.com/articles/removing-sensitive-data-from-a-repository/" style="color: rgb(216, 119, 0);">тут</a>.</p>
               <p>Если это ошибка, ответь на это письмо чтобы мы добавили это в исключения.</p>
-              <p> Найдено {{ .LeaksCount }} утечек в {{ .FilesCount }} файлах.
+              <p> Найдено {{ .LeaksCount }} утечек в {{ .FilesCount }} файлах{{ if .Severities }} ({{ range $i, $s := .Severities }}{{ if $i }}, {{ end }}{{ $s.Severity }}: {{ $s.Count }}{{ end }}){{ end }}.
             </td>
           </tr>
       </td>
@@ -130,6 +130,7 @@ const defaultTemplate = `
       <tr>
         <td bgcolor="#ffffff" align="left" style="padding: 30px 30px 10px 30px;">
           <a style="color: rgb(216, 119, 0); font-size: 22px; font-weight: normal" href="{{ .RepoURL }}">{{ .RepoURL }}</a>
+          {{ if .Owners }}<p style="color: #777777;">Владельцы: {{ range $i, $owner := .Owners }}{{ if $i }}, {{ end }}{{ $owner }}{{ end }}</p>{{ end }}
           <hr align="center" size="1" color="#111111" />
         </td>
       </tr>
@@ -137,9 +138,14 @@ const defaultTemplate = `
       {{ range .Items }}
       <tr>
         <td bgcolor="#ffffff" align="left" style="padding: 0px 30px 0px 30px; font-size: 14px;">
-          <p><a style="color: rgb(216, 119, 0); font-size: 14px;" href="{{ .RepoURL }}/blob/{{ .CommitHash }}/{{ .FilePath }}">{{ .FilePath }}</a>
+          <p>{{ if .Severity }}<b style="color: {{ if eq .Severity "critical" "high" }}#cc0000{{ else }}#777777{{ end }};">{{ .Severity }}</b> {{ end }}<a style="color: rgb(216, 119, 0); font-size: 14px;" href="{{ .RepoURL }}/blob/{{ .CommitHash }}/{{ .FilePath }}{{ if .Line }}#L{{ .Line }}{{ if .LineEnd }}-L{{ .LineEnd }}{{ end }}{{ end }}">{{ .FilePath }}{{ if .Line }}:{{ .Line }}{{ if .LineEnd }}-{{ .LineEnd }}{{ end }}{{ end }}</a>{{ if .Encoding }} <span style="color: #777777;">(закодировано: {{ .Encoding }})</span>{{ end }}
           </p>
-          <p style="background-color:#f9f9f9; font-size: 14px; font-family: 'Courier New'; color: #111111; font-weight:bold;">{{ .LeakString }}</p>
+          {{ if .ContextBefore }}<p style="background-color:#f9f9f9; font-size: 14px; font-family: 'Courier New'; color: #777777; white-space: pre-wrap; margin: 0;">{{ range $i, $line := .ContextBefore }}{{ if $i }}
+{{ end }}{{ $line }}{{ end }}</p>{{ end }}
+          <p style="background-color:#f9f9f9; font-size: 14px; font-family: 'Courier New'; color: #111111; font-weight:bold; white-space: pre-wrap;{{ if or .ContextBefore .ContextAfter }} margin: 0;{{ end }}">{{ .LeakString }}</p>
+          {{ if .ContextAfter }}<p style="background-color:#f9f9f9; font-size: 14px; font-family: 'Courier New'; color: #777777; white-space: pre-wrap; margin: 0;">{{ range $i, $line := .ContextAfter }}{{ if $i }}
+{{ end }}{{ $line }}{{ end }}</p>{{ end }}
+          {{ if or .Description .Remediation }}<p style="font-size: 12px;">{{ .Description }}{{ if .Remediation }} <a style="color: rgb(216, 119, 0);" href="{{ .Remediation }}">Как исправить</a>{{ end }}</p>{{ end }}
           <p style="font-size: 12px; text-align: right;">Commit
             <i>{{ .CommitHash }}</i> by
             <a style="color:rgb(216, 119, 0);" href="mailto:{{ .CommitEmail }}">{{ .CommitAuthor }}</a> ({{ .TimeStamp.Format "15:04:05 02.01.2006" }})</p>
@@ -9,6 +9,7 @@ import (
 
 type File struct {
 	LeaksFile string
+	Redaction hungryfox.Redaction
 }
 
 func (self *File) Start() error {
@@ -25,7 +26,7 @@ func (self *File) Send(leak hungryfox.Leak) error {
 		return err
 	}
 	defer f.Close()
-	line, _ := json.Marshal(leak)
+	line, _ := json.Marshal(leak.Redact(self.Redaction))
 	f.Write(line)
 	f.WriteString("\n")
 	return nil
@@ -10,9 +10,10 @@ import (
 )
 
 type Sender struct {
-	Method  string
-	URL     string
-	Headers map[string]string
+	Method    string
+	URL       string
+	Headers   map[string]string
+	Redaction hungryfox.Redaction
 }
 
 func (self *Sender) Start() error {
@@ -24,7 +25,7 @@ func (self *Sender) Stop() error {
 }
 
 func (self *Sender) Send(leak hungryfox.Leak) error {
-	line, _ := json.Marshal(leak)
+	line, _ := json.Marshal(leak.Redact(self.Redaction))
 
 	req, err := http.NewRequest(self.Method, self.URL, bytes.NewBuffer(line))
 	for k, v := range self.Headers {
@@ -137,11 +137,11 @@ func (s *StateManager) saveToFile() error {
 	return nil
 }
 
-func (s StateManager) Save(r hungryfox.Repo) {
+func (s *StateManager) Save(r hungryfox.Repo) {
 	s.saveRepoChan <- r
 }
 
-func (s StateManager) Load(url string) (hungryfox.RepoState, hungryfox.ScanStatus) {
+func (s *StateManager) Load(url string) (hungryfox.RepoState, hungryfox.ScanStatus) {
 	s.loadRepoChanRequest <- url
 	r := <-s.loadRepoChan
 	return r.State, r.Scan
@@ -0,0 +1,246 @@
+package leakstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	sync "github.com/sasha-s/go-deadlock"
+
+	"github.com/AlexAkulov/hungryfox"
+	"github.com/AlexAkulov/hungryfox/helpers"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/tomb.v2"
+)
+
+// maxLocations - limit of saved other places of the same leak
+const maxLocations = 100
+
+// Store - persistent storage of found leaks and their triage state
+type Store struct {
+	// Location of the database file. Leaks are kept in memory only if it is empty
+	Location string
+	// DedupByPath - the same secret in different files is different leaks
+	DedupByPath bool
+	Log         zerolog.Logger
+
+	leaks map[string]hungryfox.LeakRecord
+	dirty bool
+	mutex sync.RWMutex
+	tomb  tomb.Tomb
+}
+
+// Start - load leaks from file and start periodic saving
+func (s *Store) Start() error {
+	s.leaks = map[string]hungryfox.LeakRecord{}
+	if s.Location == "" {
+		return nil
+	}
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.tomb.Go(func() error {
+		saveTicker := time.NewTicker(time.Minute)
+		defer saveTicker.Stop()
+		for {
+			select {
+			case <-s.tomb.Dying():
+				return s.saveToFile()
+			case <-saveTicker.C:
+				if err := s.saveToFile(); err != nil {
+					s.Log.Error().Str("service", "leak store").Str("error", err.Error()).Msg("can't save leaks")
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// Stop - save leaks to file
+func (s *Store) Stop() error {
+	if s.Location == "" {
+		return nil
+	}
+	s.tomb.Kill(nil)
+	return s.tomb.Wait()
+}
+
+// Register - save found leak and return true if it should be sent to notifiers,
+// other sightings of known leak are saved as its locations
+func (s *Store) Register(leak hungryfox.Leak) bool {
+	if leak.ID == "" {
+		leak.ID = leak.Fingerprint(s.DedupByPath)
+	}
+	id := leak.ID
+	now := time.Now().UTC()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.dirty = true
+	record, ok := s.leaks[id]
+	if !ok {
+		s.leaks[id] = hungryfox.LeakRecord{
+			Leak:      leak,
+			State:     hungryfox.LeakOpen,
+			FirstSeen: now,
+			LastSeen:  now,
+			Sightings: 1,
+		}
+		return true
+	}
+	record.LastSeen = now
+	record.Sightings++
+	reopened := false
+	// resolved leak was committed again
+	if record.State == hungryfox.LeakResolved && leak.TimeStamp.After(record.ChangedAt) {
+		addLocation(&record, record.Leak.Location())
+		record.Leak = leak
+		record.State = hungryfox.LeakOpen
+		record.ChangedAt = now
+		record.ChangedBy = ""
+		record.Comment = "reopened"
+		reopened = true
+	} else {
+		addLocation(&record, leak.Location())
+	}
+	s.leaks[id] = record
+	return reopened
+}
+
+func addLocation(record *hungryfox.LeakRecord, location hungryfox.LeakLocation) {
+	if sameLocation(record.Leak.Location(), location) {
+		return
+	}
+	for _, l := range record.Locations {
+		if sameLocation(l, location) {
+			return
+		}
+	}
+	if len(record.Locations) < maxLocations {
+		record.Locations = append(record.Locations, location)
+	}
+}
+
+func sameLocation(a, b hungryfox.LeakLocation) bool {
+	return a.RepoURL == b.RepoURL && a.FilePath == b.FilePath && a.CommitHash == b.CommitHash && a.Line == b.Line
+}
+
+// merge - join records of the same leak, e.g. after change of fingerprint
+func merge(a, b hungryfox.LeakRecord) hungryfox.LeakRecord {
+	if b.FirstSeen.Before(a.FirstSeen) {
+		a, b = b, a
+	}
+	if a.State == hungryfox.LeakOpen && b.State != hungryfox.LeakOpen {
+		a.State, a.ChangedAt, a.ChangedBy, a.Comment = b.State, b.ChangedAt, b.ChangedBy, b.Comment
+	}
+	if b.LastSeen.After(a.LastSeen) {
+		a.LastSeen = b.LastSeen
+	}
+	a.Sightings += b.Sightings
+	addLocation(&a, b.Leak.Location())
+	for _, location := range b.Locations {
+		addLocation(&a, location)
+	}
+	return a
+}
+
+// Get - get leak by id
+func (s *Store) Get(id string) (hungryfox.LeakRecord, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	record, ok := s.leaks[id]
+	return record, ok
+}
+
+// List - get all leaks sorted by first seen time
+func (s *Store) List() []hungryfox.LeakRecord {
+	s.mutex.RLock()
+	result := make([]hungryfox.LeakRecord, 0, len(s.leaks))
+	for _, record := range s.leaks {
+		result = append(result, record)
+	}
+	s.mutex.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].FirstSeen.Equal(result[j].FirstSeen) {
+			return result[i].Leak.ID < result[j].Leak.ID
+		}
+		return result[i].FirstSeen.Before(result[j].FirstSeen)
+	})
+	return result
+}
+
+// SetState - change triage state of leak
+func (s *Store) SetState(id string, state hungryfox.LeakState, user, comment string) (hungryfox.LeakRecord, error) {
+	if !state.IsValid() {
+		return hungryfox.LeakRecord{}, fmt.Errorf("unknown state '%s'", state)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	record, ok := s.leaks[id]
+	if !ok {
+		return hungryfox.LeakRecord{}, fmt.Errorf("leak '%s' not found", id)
+	}
+	record.State = state
+	record.ChangedAt = time.Now().UTC()
+	record.ChangedBy = user
+	record.Comment = comment
+	s.leaks[id] = record
+	s.dirty = true
+	return record, nil
+}
+
+func (s *Store) load() error {
+	rawData, err := ioutil.ReadFile(s.Location)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't open, %v", err)
+	}
+	if len(rawData) == 0 {
+		return nil
+	}
+	records := []hungryfox.LeakRecord{}
+	if err := json.Unmarshal(rawData, &records); err != nil {
+		return fmt.Errorf("can't parse, %v", err)
+	}
+	for _, record := range records {
+		if record.Sightings == 0 {
+			record.Sightings = 1
+		}
+		record.Leak.ID = record.Leak.Fingerprint(s.DedupByPath)
+		if other, ok := s.leaks[record.Leak.ID]; ok {
+			record = merge(other, record)
+		}
+		s.leaks[record.Leak.ID] = record
+	}
+	return nil
+}
+
+func (s *Store) saveToFile() error {
+	s.mutex.Lock()
+	if !s.dirty {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.dirty = false
+	s.mutex.Unlock()
+
+	rawData, err := json.Marshal(s.List())
+	if err == nil {
+		// leaks contain secrets, so file is readable only by owner
+		err = helpers.WriteFileAtomic(s.Location, rawData, 0600)
+	}
+	if err != nil {
+		// changes are saved by next tick
+		s.mutex.Lock()
+		s.dirty = true
+		s.mutex.Unlock()
+		return fmt.Errorf("can't save, %v", err)
+	}
+	return nil
+}
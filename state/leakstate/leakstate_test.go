@@ -0,0 +1,160 @@
+package leakstate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlexAkulov/hungryfox"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStore(t *testing.T) {
+	Convey("Test leak store", t, func() {
+		dir, err := ioutil.TempDir("", "hungryfox")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		leak := hungryfox.Leak{
+			RepoURL:     "https://example.com/repo",
+			FilePath:    "config.yml",
+			PatternName: "password",
+			LeakString:  "password: qwerty",
+			CommitHash:  "hash1",
+			TimeStamp:   time.Now().Add(-time.Hour),
+		}
+		store := &Store{Location: filepath.Join(dir, "leaks.json")}
+		So(store.Start(), ShouldBeNil)
+		defer store.Stop()
+
+		Convey("new leak is notified once", func() {
+			So(store.Register(leak), ShouldBeTrue)
+			So(len(store.List()), ShouldEqual, 1)
+			record, ok := store.Get(leak.Fingerprint(false))
+			So(ok, ShouldBeTrue)
+			So(record.State, ShouldEqual, hungryfox.LeakOpen)
+			So(record.Leak.ID, ShouldEqual, leak.Fingerprint(false))
+		})
+
+		Convey("same leak in other commit has same id", func() {
+			other := leak
+			other.CommitHash = "hash2"
+			other.LeakString = "  password: qwerty "
+			So(other.Fingerprint(false), ShouldEqual, leak.Fingerprint(false))
+		})
+
+		Convey("other sightings of the same secret are saved as locations", func() {
+			So(store.Register(leak), ShouldBeTrue)
+			fork := leak
+			fork.RepoURL = "https://example.com/fork"
+			fork.CommitHash = "hash2"
+			So(store.Register(fork), ShouldBeFalse)
+			So(store.Register(fork), ShouldBeFalse)
+			moved := leak
+			moved.FilePath = "config/prod.yml"
+			moved.CommitHash = "hash3"
+			So(store.Register(moved), ShouldBeFalse)
+
+			So(len(store.List()), ShouldEqual, 1)
+			record, _ := store.Get(leak.Fingerprint(false))
+			So(record.Leak.RepoURL, ShouldEqual, leak.RepoURL)
+			So(record.Sightings, ShouldEqual, 4)
+			So(record.Locations, ShouldResemble, []hungryfox.LeakLocation{fork.Location(), moved.Location()})
+		})
+
+		Convey("the same secret in other file is other leak if dedup by path", func() {
+			store := &Store{DedupByPath: true}
+			So(store.Start(), ShouldBeNil)
+			So(store.Register(leak), ShouldBeTrue)
+			moved := leak
+			moved.FilePath = "config/prod.yml"
+			So(store.Register(moved), ShouldBeTrue)
+			So(len(store.List()), ShouldEqual, 2)
+		})
+
+		Convey("records are joined by new fingerprint on load", func() {
+			old := hungryfox.LeakRecord{Leak: leak, State: hungryfox.LeakOpen, FirstSeen: time.Now().Add(-time.Hour)}
+			old.Leak.ID = "old-id-1"
+			other := hungryfox.LeakRecord{Leak: leak, State: hungryfox.LeakFalsePositive, ChangedBy: "alice", FirstSeen: time.Now()}
+			other.Leak.ID = "old-id-2"
+			other.Leak.RepoURL = "https://example.com/fork"
+			rawData, _ := json.Marshal([]hungryfox.LeakRecord{old, other})
+			So(ioutil.WriteFile(store.Location, rawData, 0644), ShouldBeNil)
+
+			newStore := &Store{Location: store.Location}
+			So(newStore.Start(), ShouldBeNil)
+			defer newStore.Stop()
+			So(len(newStore.List()), ShouldEqual, 1)
+			record, ok := newStore.Get(leak.Fingerprint(false))
+			So(ok, ShouldBeTrue)
+			So(record.Leak.RepoURL, ShouldEqual, leak.RepoURL)
+			So(record.State, ShouldEqual, hungryfox.LeakFalsePositive)
+			So(record.ChangedBy, ShouldEqual, "alice")
+			So(record.Sightings, ShouldEqual, 2)
+			So(len(record.Locations), ShouldEqual, 1)
+			So(record.Locations[0].RepoURL, ShouldEqual, other.Leak.RepoURL)
+		})
+
+		Convey("triaged leak is not notified", func() {
+			store.Register(leak)
+			_, err := store.SetState(leak.Fingerprint(false), hungryfox.LeakFalsePositive, "alice", "test data")
+			So(err, ShouldBeNil)
+			So(store.Register(leak), ShouldBeFalse)
+			record, _ := store.Get(leak.Fingerprint(false))
+			So(record.State, ShouldEqual, hungryfox.LeakFalsePositive)
+			So(record.ChangedBy, ShouldEqual, "alice")
+		})
+
+		Convey("resolved leak is reopened by new commit", func() {
+			store.Register(leak)
+			_, err := store.SetState(leak.Fingerprint(false), hungryfox.LeakResolved, "alice", "")
+			So(err, ShouldBeNil)
+			So(store.Register(leak), ShouldBeFalse)
+			leak.TimeStamp = time.Now().Add(time.Minute)
+			So(store.Register(leak), ShouldBeTrue)
+			record, _ := store.Get(leak.Fingerprint(false))
+			So(record.State, ShouldEqual, hungryfox.LeakOpen)
+		})
+
+		Convey("bad state and unknown leak", func() {
+			store.Register(leak)
+			_, err := store.SetState(leak.Fingerprint(false), "fixed", "alice", "")
+			So(err, ShouldNotBeNil)
+			_, err = store.SetState("unknown", hungryfox.LeakResolved, "alice", "")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("state is saved to file", func() {
+			store.Register(leak)
+			store.SetState(leak.Fingerprint(false), hungryfox.LeakAcceptedRisk, "bob", "")
+			So(store.Stop(), ShouldBeNil)
+			info, err := os.Stat(store.Location)
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+
+			newStore := &Store{Location: store.Location}
+			So(newStore.Start(), ShouldBeNil)
+			defer newStore.Stop()
+			record, ok := newStore.Get(leak.Fingerprint(false))
+			So(ok, ShouldBeTrue)
+			So(record.State, ShouldEqual, hungryfox.LeakAcceptedRisk)
+			So(record.ChangedBy, ShouldEqual, "bob")
+		})
+
+		Convey("failed save is retried", func() {
+			store.Register(leak)
+			location := store.Location
+			store.Location = dir
+			So(store.saveToFile(), ShouldNotBeNil)
+			store.Location = location
+			So(store.saveToFile(), ShouldBeNil)
+			rawData, err := ioutil.ReadFile(location)
+			So(err, ShouldBeNil)
+			So(string(rawData), ShouldContainSubstring, leak.Fingerprint(false))
+		})
+	})
+}